@@ -0,0 +1,88 @@
+package swaggerlt
+
+import (
+	"fmt"
+	"github.com/dave/jennifer/jen"
+	"path"
+	"sort"
+	"strings"
+)
+
+// apiMethod is the signature of a generated operation method, kept to render the interfaces.
+type apiMethod struct {
+	name   string
+	doc    string
+	params []*apiParam
+	// response is the type of the response, nil when the operation only returns an error
+	response *jen.Statement
+	imports  []string
+}
+
+type apiParam struct {
+	name string
+	typ  *jen.Statement
+}
+
+func (m *apiMethod) signature() (params, results []jen.Code) {
+	for _, p := range m.params {
+		params = append(params, jen.Id(p.name).Add(p.typ))
+	}
+	if m.response != nil {
+		results = append(results, jen.Id("response").Add(m.response))
+	}
+	results = append(results, jen.Err().Error())
+	return
+}
+
+// addMethod records the method of a built operation for the interface of its receiver.
+func (g *Generator) addMethod(nfp, tag string, m *apiMethod) {
+	if g.methods == nil {
+		g.methods = map[string]map[string][]*apiMethod{}
+	}
+	if g.methods[nfp] == nil {
+		g.methods[nfp] = map[string][]*apiMethod{}
+	}
+	g.methods[nfp][tag] = append(g.methods[nfp][tag], m)
+}
+
+// createAPIFile renders the API interface implemented by Client and one interface per sub-client,
+// each with a compile time assertion. It is called after the operations of the package were built.
+func (g *Generator) createAPIFile(name string, ops []*Operation) error {
+	nfp := g.clientPackage(name)
+	f := jen.NewFilePath(nfp)
+
+	tags, specTags := g.clientTags(nfp, ops)
+
+	addInterface := func(typeName, tag string, extra ...jen.Code) {
+		methods := g.methods[nfp][tag]
+		sort.Slice(methods, func(i, j int) bool { return methods[i].name < methods[j].name })
+
+		var members []jen.Code
+		for _, m := range methods {
+			for _, im := range m.imports {
+				f.ImportAlias(im, path.Base(im)+"_")
+			}
+			params, results := m.signature()
+			members = append(members, jen.Comment(m.doc), jen.Id(m.name).Params(params...).Params(results...))
+		}
+		members = append(members, extra...)
+
+		apiName := strings.TrimSuffix(typeName, "Client") + "API"
+		f.Comment(fmt.Sprintf("%s lists the operations of %s so it can be replaced in tests.", apiName, typeName))
+		f.Type().Id(apiName).Interface(members...)
+		f.Var().Id("_").Id(apiName).Op("=").Parens(jen.Op("*").Id(typeName)).Parens(jen.Nil())
+	}
+
+	var accessors []jen.Code
+	for _, tag := range tags {
+		accessors = append(accessors,
+			jen.Comment(fmt.Sprintf("%s returns the operations tagged %s.", tag, specTags[tag])),
+			jen.Id(tag).Params().Id(tag+"API"))
+	}
+	addInterface("Client", "", accessors...)
+	for _, tag := range tags {
+		addInterface(tag+"Client", tag)
+	}
+
+	return g.render(g.outputName(nfp, "api.go"), f)
+}
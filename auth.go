@@ -0,0 +1,121 @@
+package swaggerlt
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+)
+
+// Credential authenticates a request for a security scheme.
+type Credential interface {
+	Apply(request *http.Request) error
+}
+
+// SecurityRequirement lists the security schemes which must all be applied to a request.
+type SecurityRequirement []string
+
+// APIKey sends Key in the header or query parameter Name.
+type APIKey struct {
+	Name string
+	// In is header or query.
+	In  string
+	Key string
+}
+
+func (a *APIKey) Apply(request *http.Request) error {
+	switch a.In {
+	case "header":
+		request.Header.Set(a.Name, a.Key)
+	case "query":
+		query := request.URL.Query()
+		query.Set(a.Name, a.Key)
+		request.URL.RawQuery = query.Encode()
+	default:
+		return fmt.Errorf("api key %s : unsupported location %q", a.Name, a.In)
+	}
+	return nil
+}
+
+// BasicAuth sends http basic authentication.
+type BasicAuth struct {
+	Username string
+	Password string
+}
+
+func (b *BasicAuth) Apply(request *http.Request) error {
+	request.SetBasicAuth(b.Username, b.Password)
+	return nil
+}
+
+// TokenSource provides the access tokens of a BearerToken.
+type TokenSource interface {
+	Token(ctx context.Context) (string, error)
+}
+
+// StaticToken is a TokenSource that always returns the same token.
+type StaticToken string
+
+func (t StaticToken) Token(_ context.Context) (string, error) {
+	return string(t), nil
+}
+
+// BearerToken sends the token of Source in the Authorization header.
+type BearerToken struct {
+	Source TokenSource
+}
+
+func (b *BearerToken) Apply(request *http.Request) error {
+	token, err := b.Source.Token(request.Context())
+	if err != nil {
+		return fmt.Errorf("bearer token : %w", err)
+	}
+	request.Header.Set("Authorization", "Bearer "+token)
+	return nil
+}
+
+// Invalidator is implemented by credentials and token sources which cache tokens. Invalidate drops the
+// cached token and reports whether a new one will be used, RequestHelper then retries a request answered with 401.
+type Invalidator interface {
+	Invalidate() bool
+}
+
+func (b *BearerToken) Invalidate() bool {
+	if invalidator, ok := b.Source.(Invalidator); ok {
+		return invalidator.Invalidate()
+	}
+	return false
+}
+
+// invalidate invalidates the credentials and reports whether any of them will use a new token.
+func invalidate(credentials []Credential) (retry bool) {
+	for _, credential := range credentials {
+		if invalidator, ok := credential.(Invalidator); ok && invalidator.Invalidate() {
+			retry = true
+		}
+	}
+	return
+}
+
+// authenticate applies the credentials of the first security requirement all credentials are present for
+// and returns them. The request is sent unauthenticated when no requirement can be met, authentication
+// may be added by middleware or the http client instead.
+func (u *RequestHelper) authenticate(request *http.Request) ([]Credential, error) {
+	for _, requirement := range u.Security {
+		var credentials []Credential
+		for _, scheme := range requirement {
+			if credential := u.Credentials[scheme]; credential != nil {
+				credentials = append(credentials, credential)
+			}
+		}
+		if len(credentials) < len(requirement) {
+			continue
+		}
+		for i, credential := range credentials {
+			if err := credential.Apply(request); err != nil {
+				return nil, fmt.Errorf("security scheme %s : %w", requirement[i], err)
+			}
+		}
+		return credentials, nil
+	}
+	return nil, nil
+}
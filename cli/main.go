@@ -1,26 +1,335 @@
 package main
 
 import (
+	"encoding/json"
+	"errors"
+	"flag"
 	"fmt"
 	"github.com/mlctrez/swaggerlt"
+	"io"
 	"os"
 	"regexp"
+	"strings"
+	"text/tabwriter"
 )
 
+const (
+	exitOk    = 0
+	exitError = 1
+	exitUsage = 2
+)
+
+const usage = `swaggerlt generates a golang client from a swagger 2.0 or OpenAPI 3.x spec.
+
+Usage:
+
+	swaggerlt <command> [flags]
+
+Commands:
+
+	generate         generate the client packages
+	diff             report generated files that differ from -out, without writing
+	validate         check that the spec can be generated
+	list-operations  list the operations matching the path regex
+
+Without -spec the targets of -config are used, which defaults to the first of
+swaggerlt.yaml, swaggerlt.yml or swaggerlt.json found in the working directory.
+
+Run 'swaggerlt <command> -h' for the flags of a command.
+`
+
+var errUsage = errors.New("usage")
+
+// errReported is returned when the problems were already written as a report.
+var errReported = errors.New("reported")
+
+type command struct {
+	flags    *flag.FlagSet
+	config   string
+	spec     string
+	paths    string
+	module   string
+	service  string
+	output   string
+	imports  string
+	layout   string
+	byTag    bool
+	fakes    bool
+	context  bool
+	format   string
+	verbose  bool
+	generate bool
+
+	stdout io.Writer
+	stderr io.Writer
+}
+
 func main() {
+	os.Exit(run(os.Args[1:], os.Stdout, os.Stderr))
+}
+
+func run(args []string, stdout, stderr io.Writer) int {
+	if len(args) == 0 {
+		_, _ = fmt.Fprint(stderr, usage)
+		return exitUsage
+	}
 
-	options := &swaggerlt.Options{SpecFile: "spec.json", PathRegex: regexp.MustCompile("/v0"),
-		ModuleName: "github.com/mlctrez/swaggerlt", ServiceName: "smapi",
+	var action func(c *command) error
+	switch args[0] {
+	case "generate":
+		action = generate
+	case "diff":
+		action = diff
+	case "validate":
+		action = validate
+	case "list-operations":
+		action = listOperations
+	case "-h", "-help", "--help", "help":
+		_, _ = fmt.Fprint(stdout, usage)
+		return exitOk
+	default:
+		_, _ = fmt.Fprintf(stderr, "unknown command %q\n\n%s", args[0], usage)
+		return exitUsage
 	}
 
-	if generator, err := swaggerlt.New(options); err != nil {
-		fmt.Println(err)
-		os.Exit(1)
+	c := newCommand(args[0], stdout, stderr)
+	if err := c.flags.Parse(args[1:]); err != nil {
+		if errors.Is(err, flag.ErrHelp) {
+			return exitOk
+		}
+		return exitUsage
+	}
+
+	if err := action(c); err != nil {
+		if errors.Is(err, errUsage) {
+			c.flags.Usage()
+			return exitUsage
+		}
+		if errors.Is(err, errReported) {
+			return exitError
+		}
+		_, _ = fmt.Fprintln(stderr, err)
+		return exitError
+	}
+	return exitOk
+}
+
+func newCommand(name string, stdout, stderr io.Writer) *command {
+	c := &command{flags: flag.NewFlagSet(name, flag.ContinueOnError), stdout: stdout, stderr: stderr}
+	c.generate = name == "generate" || name == "diff"
+
+	fs := c.flags
+	fs.SetOutput(stderr)
+	fs.StringVar(&c.config, "config", "", "config file with generation targets, used when -spec is not set")
+	fs.StringVar(&c.spec, "spec", "", "path to the swagger or OpenAPI spec, json or yaml")
+	fs.StringVar(&c.paths, "paths", ".*", "regular expression selecting the spec paths to generate")
+	fs.StringVar(&c.module, "module", "", "go module or import path root of the generated packages")
+	fs.StringVar(&c.service, "service", "", "service name used as the prefix of generated package names")
+	fs.StringVar(&c.layout, "layout", "version", "client package layout, version, tag, flat or regex:<expression> with a capture group")
+	fs.BoolVar(&c.byTag, "group-by-tag", false, "generate a sub-client per tag, like client.Users().Get()")
+	if c.generate {
+		fs.BoolVar(&c.fakes, "fakes", false, "generate FakeClient, an in-memory implementation of the client API for tests")
+		fs.BoolVar(&c.context, "context", false, "add a context.Context as the first parameter of the operation methods")
+		fs.StringVar(&c.output, "out", "", "directory the generated packages are written to, the working directory when empty")
+		fs.StringVar(&c.imports, "import", "", "import path of -out, defaults to -module joined with -out, required for an absolute -out")
+	}
+	if name != "list-operations" {
+		fs.StringVar(&c.format, "format", "text", "report format, text or json")
+	}
+	fs.BoolVar(&c.verbose, "v", false, "verbose output")
+	fs.Usage = func() {
+		_, _ = fmt.Fprintf(stderr, "Usage: swaggerlt %s [flags]\n\nFlags:\n", name)
+		fs.PrintDefaults()
+	}
+	return c
+}
+
+// generators returns a generator for -spec or one for each target of the config file.
+func (c *command) generators() (result []*swaggerlt.Generator, err error) {
+	var options []*swaggerlt.Options
+	if c.spec != "" {
+		var o *swaggerlt.Options
+		if o, err = c.options(); err != nil {
+			return
+		}
+		options = append(options, o)
 	} else {
-		if err = generator.Execute(); err != nil {
-			fmt.Println(err)
-			os.Exit(1)
+		if c.config == "" {
+			c.config = swaggerlt.FindConfig(".")
+		}
+		if c.config == "" {
+			return nil, errUsage
 		}
+		var config *swaggerlt.Config
+		if config, err = swaggerlt.LoadConfig(c.config); err != nil {
+			return
+		}
+		if options, err = config.Options(); err != nil {
+			return
+		}
+	}
+
+	for _, o := range options {
+		o.Verbose = c.verbose
+		var generator *swaggerlt.Generator
+		if generator, err = swaggerlt.New(o); err != nil {
+			return
+		}
+		result = append(result, generator)
+	}
+	return
+}
+
+func (c *command) options() (*swaggerlt.Options, error) {
+	if c.generate && (c.module == "" || c.service == "") {
+		return nil, errUsage
+	}
+
+	regex, err := regexp.Compile(c.paths)
+	if err != nil {
+		return nil, fmt.Errorf("invalid -paths : %w", err)
 	}
 
+	layout, err := swaggerlt.ParseLayout(c.layout)
+	if err != nil {
+		return nil, fmt.Errorf("invalid -layout : %w", err)
+	}
+
+	options := &swaggerlt.Options{SpecFile: c.spec, PathRegex: regex,
+		ModuleName: c.module, ServiceName: c.service,
+		OutputDir: c.output, ImportPath: c.imports, Layout: layout,
+		GroupByTag: c.byTag, Fakes: c.fakes, Context: c.context,
+	}
+	return options, nil
+}
+
+func generate(c *command) (err error) {
+	return c.run(func(generator *swaggerlt.Generator) (*targetReport, error) {
+		return &targetReport{}, generator.Execute()
+	})
+}
+
+func validate(c *command) (err error) {
+	return c.run(func(generator *swaggerlt.Generator) (*targetReport, error) {
+		return &targetReport{}, generator.Validate()
+	})
+}
+
+type targetReport struct {
+	Spec    string                  `json:"spec"`
+	Report  *swaggerlt.Report       `json:"report"`
+	Changes []*swaggerlt.FileChange `json:"changes,omitempty"`
+}
+
+// diff reports the files that generation would add, remove or change and fails when there are any.
+func diff(c *command) (err error) {
+	drift := false
+	err = c.run(func(generator *swaggerlt.Generator) (*targetReport, error) {
+		changes, err := generator.Diff()
+		drift = drift || len(changes) > 0
+		return &targetReport{Changes: changes}, err
+	})
+	if err == nil && drift {
+		return errReported
+	}
+	return
+}
+
+// run calls action on each generator and writes the resulting reports in the requested format.
+func (c *command) run(action func(*swaggerlt.Generator) (*targetReport, error)) (err error) {
+	if c.format != "text" && c.format != "json" {
+		return errUsage
+	}
+
+	var generators []*swaggerlt.Generator
+	if generators, err = c.generators(); err != nil {
+		return
+	}
+
+	var reports []*targetReport
+	failed := false
+	for _, generator := range generators {
+		var tr *targetReport
+		if tr, err = action(generator); err != nil {
+			var report *swaggerlt.Report
+			if !errors.As(err, &report) {
+				return fmt.Errorf("%s : %w", generator.Options.SpecFile, err)
+			}
+			failed = true
+		}
+		tr.Spec = generator.Options.SpecFile
+		tr.Report = generator.Report()
+		reports = append(reports, tr)
+	}
+
+	if err = c.writeReports(reports); err != nil {
+		return
+	}
+	if failed {
+		return errReported
+	}
+	return nil
+}
+
+func (c *command) writeReports(reports []*targetReport) (err error) {
+	if c.format == "json" {
+		encoder := json.NewEncoder(c.stdout)
+		encoder.SetIndent("", "  ")
+		return encoder.Encode(reports)
+	}
+
+	for _, tr := range reports {
+		for _, change := range tr.Changes {
+			_, _ = fmt.Fprintf(c.stdout, "%s %s\n", change.Kind, change.Name)
+		}
+		for _, change := range tr.Changes {
+			_, _ = fmt.Fprint(c.stdout, change.Diff)
+		}
+		if len(tr.Report.Diagnostics) == 0 {
+			if !c.generate {
+				_, _ = fmt.Fprintf(c.stdout, "%s is valid\n", tr.Spec)
+			}
+			continue
+		}
+		_, _ = fmt.Fprintf(c.stderr, "%s:\n", tr.Spec)
+		if err = tr.Report.WriteText(c.stderr); err != nil {
+			return
+		}
+	}
+	return
+}
+
+func listOperations(c *command) (err error) {
+	var generators []*swaggerlt.Generator
+	if generators, err = c.generators(); err != nil {
+		return
+	}
+
+	w := tabwriter.NewWriter(c.stdout, 0, 4, 2, ' ', 0)
+	_, _ = fmt.Fprintln(w, "SERVICE\tVERB\tPATH\tNAME\tTAGS")
+	failed := false
+	for _, generator := range generators {
+		var ops []*swaggerlt.Operation
+		if ops, err = generator.Operations(); err != nil {
+			return
+		}
+		service := generator.Options.ServiceName
+		for _, op := range ops {
+			verb := strings.ToUpper(op.Verb)
+			_, _ = fmt.Fprintf(w, "%s\t%s\t%s\t%s\t%s\n", service, verb, op.Path, op.GoName, strings.Join(op.Tags, ","))
+		}
+		if report := generator.Report(); report.HasErrors() {
+			// operations that could not be read are left out of the list
+			failed = true
+			_, _ = fmt.Fprintf(c.stderr, "%s:\n", generator.Options.SpecFile)
+			_ = report.WriteText(c.stderr)
+		}
+	}
+	if err = w.Flush(); err != nil {
+		return
+	}
+	if failed {
+		return errReported
+	}
+	return nil
 }
@@ -2,41 +2,268 @@ package swaggerlt
 
 import (
 	"fmt"
+	jp "github.com/buger/jsonparser"
 	"github.com/dave/jennifer/jen"
-	"os"
-	"path/filepath"
+	"sort"
+	"strings"
 )
 
-func (g *Generator) createClientFile(version string) error {
+// createClientFile renders the Client of the named Layout package and the sub-clients of the tags of its ops.
+func (g *Generator) createClientFile(name string, ops []*Operation) error {
 
-	nfp := filepath.Join(g.Options.ModuleName, g.Options.ServiceName+version, "client")
+	nfp := g.clientPackage(name)
 
 	f := jen.NewFilePath(nfp)
-	f.HeaderComment("Automatically generated, do not edit!")
 
-	doc := "Client for service %s version %s."
-	f.Comment(fmt.Sprintf(doc, g.Options.ServiceName, version))
+	doc := fmt.Sprintf("Client for service %s.", g.Options.ServiceName)
+	if name != "" {
+		doc = fmt.Sprintf("Client for service %s %s.", g.Options.ServiceName, name)
+	}
+	f.Comment(doc)
 
 	f.Type().Id("Client").Struct(
 		jen.Id("Client").Op("*").Qual("net/http", "Client"),
 		jen.Id("Endpoint").String(),
+		jen.Comment("BasePath is inserted between Endpoint and the operation paths."),
+		jen.Id("BasePath").String(),
+		jen.Comment("Headers are sent with every request."),
+		jen.Id("Headers").Map(jen.String()).String(),
+		jen.Comment("Middleware wraps the sending of every request, the first one is the outermost."),
+		jen.Id("Middleware").Index().Qual(runtimePackage, "Middleware"),
+		jen.Comment("Credentials are applied to the operations requiring their security scheme, keyed by scheme name."),
+		jen.Id("Credentials").Map(jen.String()).Qual(runtimePackage, "Credential"),
+		jen.Comment("Retry retries failed requests, they are sent once when nil."),
+		jen.Id("Retry").Op("*").Qual(runtimePackage, "RetryPolicy"),
+		jen.Comment("Limiter delays requests to stay within rate limits, they are not delayed when nil."),
+		jen.Id("Limiter").Qual(runtimePackage, "Limiter"),
 	)
 
-	versionDir := g.versionDirectory(version)
-	if err := os.MkdirAll(versionDir, 0755); err != nil {
-		return err
+	g.clientConstructor(f)
+
+	tags, specTags := g.clientTags(nfp, ops)
+	for _, tag := range tags {
+		typeName := tag + "Client"
+		f.Comment(fmt.Sprintf("%s holds the operations tagged %s.", typeName, specTags[tag]))
+		f.Type().Id(typeName).Struct(jen.Id("client").Op("*").Id("Client"))
+		f.Comment(fmt.Sprintf("%s returns the operations tagged %s, sharing the http client and endpoint of s.", tag, specTags[tag]))
+		f.Func().Params(jen.Id("s").Op("*").Id("Client")).Id(tag).Params().Id(tag + "API").Block(
+			jen.Return(jen.Op("&").Id(typeName).Values(jen.Dict{jen.Id("client"): jen.Id("s")})),
+		)
+	}
+
+	return g.render(g.outputName(nfp, "client.go"), f)
+}
+
+// clientTags returns the sorted sub-client names of the ops in the client package nfp,
+// along with the spec tag of each name.
+func (g *Generator) clientTags(nfp string, ops []*Operation) (tags []string, specTags map[string]string) {
+	specTags = map[string]string{}
+	for _, op := range ops {
+		tag := g.operationTag(op)
+		if _, ok := specTags[tag]; tag != "" && !ok && g.operationPackage(op) == nfp {
+			specTags[tag] = op.Tags[0]
+			tags = append(tags, tag)
+		}
 	}
+	sort.Strings(tags)
+	return
+}
+
+// runtimePackage is imported by the generated clients for RequestHelper and the runtime types.
+const runtimePackage = "github.com/mlctrez/swaggerlt"
+
+// clientFields are the fields of the generated Client, operations and sub-client accessors must not use these names.
+var clientFields = []string{"Client", "Endpoint", "BasePath", "Headers", "Middleware", "Credentials", "Retry", "Limiter"}
 
-	clientFilePath := filepath.Join(versionDir, "client", "client.go")
-	if err := os.MkdirAll(filepath.Dir(clientFilePath), 0755); err != nil {
-		return err
+// clientOptions are the options generated for every Client, security scheme options must not use these names.
+var clientOptions = []string{"WithHTTPClient", "WithUserAgent", "WithHeader", "WithTimeout", "WithBasePath",
+	"WithMiddleware", "WithCredential", "WithRetry", "WithLimiter"}
+
+func isClientField(name string) bool {
+	for _, field := range clientFields {
+		if strings.EqualFold(field, name) {
+			return true
+		}
 	}
+	return false
+}
+
+func isClientOption(name string) bool {
+	for _, option := range clientOptions {
+		if option == name {
+			return true
+		}
+	}
+	return false
+}
+
+// clientConstructor renders NewClient, its options and the newRequest method the operations start with.
+func (g *Generator) clientConstructor(f *jen.File) {
+	client := func() *jen.Statement { return jen.Id("s").Op("*").Id("Client") }
+	option := func(name string, params []jen.Code, body ...jen.Code) {
+		f.Func().Id(name).Params(params...).Id("Option").Block(
+			jen.Return(jen.Func().Params(client()).Block(body...)),
+		)
+	}
+
+	f.Comment("Option configures a Client created by NewClient.")
+	f.Type().Id("Option").Func().Params(client())
+
+	server := g.specServer()
+	var schemes []jen.Code
+	for _, scheme := range server.schemes {
+		schemes = append(schemes, jen.Lit(scheme))
+	}
+	f.Const().Defs(
+		jen.Comment("Host is the host of the service from the spec."),
+		jen.Id("Host").Op("=").Lit(server.host),
+		jen.Comment("BasePath is the path from the spec the operation paths are relative to."),
+		jen.Id("BasePath").Op("=").Lit(server.basePath),
+		jen.Comment("DefaultEndpoint is the preferred scheme with Host, it does not include BasePath."),
+		jen.Id("DefaultEndpoint").Op("=").Lit(server.endpoint()),
+	)
+	f.Comment("Schemes are the schemes of the service from the spec.")
+	f.Var().Id("Schemes").Op("=").Index().String().Values(schemes...)
+
+	f.Comment("NewClient returns a Client for endpoint using http.DefaultClient and BasePath, the options are applied in order.\n" +
+		"An empty endpoint uses DefaultEndpoint. The endpoint should not include BasePath, use WithBasePath to change it.")
+	defaults := []jen.Code{
+		jen.If(jen.Id("endpoint").Op("==").Lit("")).Block(
+			jen.Id("endpoint").Op("=").Id("DefaultEndpoint"),
+		),
+	}
+	f.Func().Id("NewClient").Params(jen.Id("endpoint").String(), jen.Id("opts").Op("...").Id("Option")).Op("*").Id("Client").Block(
+		append(defaults,
+			jen.Id("s").Op(":=").Op("&").Id("Client").Values(jen.Dict{
+				jen.Id("Client"):      jen.Qual("net/http", "DefaultClient"),
+				jen.Id("Endpoint"):    jen.Id("endpoint"),
+				jen.Id("BasePath"):    jen.Id("BasePath"),
+				jen.Id("Headers"):     jen.Map(jen.String()).String().Values(),
+				jen.Id("Credentials"): jen.Map(jen.String()).Qual(runtimePackage, "Credential").Values(),
+			}),
+			jen.For(jen.List(jen.Id("_"), jen.Id("opt")).Op(":=").Range().Id("opts")).Block(
+				jen.Id("opt").Call(jen.Id("s")),
+			),
+			jen.Return(jen.Id("s")),
+		)...,
+	)
+
+	f.Comment("WithHTTPClient sends the requests with client.")
+	option("WithHTTPClient", []jen.Code{jen.Id("client").Op("*").Qual("net/http", "Client")},
+		jen.Id("s").Dot("Client").Op("=").Id("client"))
+
+	f.Comment("WithUserAgent sets the User-Agent header of every request.")
+	option("WithUserAgent", []jen.Code{jen.Id("userAgent").String()},
+		jen.Id("s").Dot("Headers").Index(jen.Lit("User-Agent")).Op("=").Id("userAgent"))
+
+	f.Comment("WithHeader sets a header sent with every request.")
+	option("WithHeader", []jen.Code{jen.List(jen.Id("name"), jen.Id("value")).String()},
+		jen.Id("s").Dot("Headers").Index(jen.Id("name")).Op("=").Id("value"))
+
+	f.Comment("WithTimeout sets the timeout of a copy of the http client, use it after WithHTTPClient.")
+	option("WithTimeout", []jen.Code{jen.Id("timeout").Qual("time", "Duration")},
+		jen.Id("client").Op(":=").Op("*").Id("s").Dot("Client"),
+		jen.Id("client").Dot("Timeout").Op("=").Id("timeout"),
+		jen.Id("s").Dot("Client").Op("=").Op("&").Id("client"))
+
+	f.Comment("WithBasePath replaces the BasePath of the spec inserted between the endpoint and the operation paths.")
+	option("WithBasePath", []jen.Code{jen.Id("basePath").String()},
+		jen.Id("s").Dot("BasePath").Op("=").Id("basePath"))
+
+	f.Comment("WithMiddleware appends middleware wrapping the sending of every request.")
+	option("WithMiddleware", []jen.Code{jen.Id("middleware").Op("...").Qual(runtimePackage, "Middleware")},
+		jen.Id("s").Dot("Middleware").Op("=").Append(jen.Id("s").Dot("Middleware"), jen.Id("middleware").Op("...")))
+
+	f.Comment("WithRetry retries failed requests following policy.")
+	option("WithRetry", []jen.Code{jen.Id("policy").Op("*").Qual(runtimePackage, "RetryPolicy")},
+		jen.Id("s").Dot("Retry").Op("=").Id("policy"))
+
+	f.Comment("WithLimiter delays requests with limiter, like a swaggerlt.RateLimiter or swaggerlt.OperationLimiter.")
+	option("WithLimiter", []jen.Code{jen.Id("limiter").Qual(runtimePackage, "Limiter")},
+		jen.Id("s").Dot("Limiter").Op("=").Id("limiter"))
+
+	g.securityOptions(f, option)
+
+	f.Comment("newRequest returns the request helper of an operation with the settings of s applied.")
+	f.Func().Params(client()).Id("newRequest").Params(jen.List(jen.Id("operation"), jen.Id("method"), jen.Id("uri")).String()).
+		Op("*").Qual(runtimePackage, "RequestHelper").Block(
+		jen.Id("h").Op(":=").Qual(runtimePackage, "NewRequestHelper").Call(jen.Id("method"), jen.Id("s").Dot("Endpoint"), jen.Id("uri")),
+		jen.Id("h").Dot("BasePath").Op("=").Id("s").Dot("BasePath"),
+		jen.For(jen.List(jen.Id("name"), jen.Id("value")).Op(":=").Range().Id("s").Dot("Headers")).Block(
+			jen.Id("h").Dot("Header").Call(jen.Id("name"), jen.Id("value")),
+		),
+		jen.Id("h").Dot("Middleware").Op("=").Id("s").Dot("Middleware"),
+		jen.Id("h").Dot("Credentials").Op("=").Id("s").Dot("Credentials"),
+		jen.Id("h").Dot("Retry").Op("=").Id("s").Dot("Retry"),
+		jen.Id("h").Dot("Operation").Op("=").Id("operation"),
+		jen.Id("h").Dot("Limiter").Op("=").Id("s").Dot("Limiter"),
+		jen.Return(jen.Id("h")),
+	)
+}
+
+// specServer is the location of the service as described by the spec.
+type specServer struct {
+	host     string
+	basePath string
+	schemes  []string
+}
+
+// endpoint returns the first scheme, preferring https, with host. It is empty when the spec has no host.
+func (s *specServer) endpoint() string {
+	if s.host == "" {
+		return ""
+	}
+	scheme := "https"
+	if len(s.schemes) > 0 {
+		scheme = s.schemes[0]
+	}
+	for _, other := range s.schemes {
+		if other == "https" {
+			scheme = other
+		}
+	}
+	return scheme + "://" + s.host
+}
+
+// specServer returns the server of the spec, it is read once per run.
+func (g *Generator) specServer() *specServer {
+	if g.server == nil {
+		g.server = g.readSpecServer()
+	}
+	return g.server
+}
 
-	if create, err := os.Create(clientFilePath); err != nil {
-		return err
-	} else {
-		defer func() { _ = create.Close() }()
-		return f.Render(create)
+// readSpecServer reads the schemes, host and basePath of a swagger spec or splits the url of the first
+// server of an OpenAPI 3 spec into them, after substituting the defaults of its variables.
+func (g *Generator) readSpecServer() (server *specServer) {
+	server = &specServer{}
+	if g.openAPI3 {
+		serverURL, _ := jp.GetString(g.specBytes, "servers", "[0]", "url")
+		_ = jp.ObjectEach(g.specBytes, func(key []byte, value []byte, _ jp.ValueType, _ int) error {
+			if value, err := jp.GetString(value, "default"); err == nil {
+				serverURL = strings.ReplaceAll(serverURL, "{"+string(key)+"}", value)
+			}
+			return nil
+		}, "servers", "[0]", "variables")
+		if strings.Contains(serverURL, "{") {
+			g.report.warnf(jsonPointer("servers", "0", "url"),
+				"server url %s has variables without a default, Host, BasePath and DefaultEndpoint are left empty", serverURL)
+			return
+		}
+		if scheme, rest, ok := strings.Cut(serverURL, "://"); ok {
+			server.schemes = []string{scheme}
+			server.host, server.basePath, _ = strings.Cut(rest, "/")
+			server.basePath = "/" + server.basePath
+		} else {
+			server.basePath = serverURL
+		}
+		return
 	}
 
+	server.host, _ = jp.GetString(g.specBytes, "host")
+	server.basePath, _ = jp.GetString(g.specBytes, "basePath")
+	_, _ = jp.ArrayEach(g.specBytes, func(value []byte, _ jp.ValueType, _ int, _ error) {
+		server.schemes = append(server.schemes, string(value))
+	}, "schemes")
+	return
 }
@@ -0,0 +1,39 @@
+package swaggerlt
+
+import (
+	"testing"
+)
+
+func TestSpecServer(t *testing.T) {
+	for _, test := range []struct {
+		name     string
+		spec     string
+		host     string
+		basePath string
+		endpoint string
+		warnings int
+	}{
+		{"swagger", `{"swagger": "2.0", "host": "api.example.com", "basePath": "/v1", "schemes": ["http", "https"]}`,
+			"api.example.com", "/v1", "https://api.example.com", 0},
+		{"openapi", `{"openapi": "3.0.3", "servers": [{"url": "http://api.example.com/v1"}]}`,
+			"api.example.com", "/v1", "http://api.example.com", 0},
+		{"variables", `{"openapi": "3.0.3", "servers": [{"url": "https://{region}.example.com/{version}",
+			"variables": {"region": {"default": "eu"}, "version": {"default": "v2"}}}]}`,
+			"eu.example.com", "/v2", "https://eu.example.com", 0},
+		{"variable without default", `{"openapi": "3.0.3", "servers": [{"url": "https://{region}.example.com/v1"}]}`,
+			"", "", "", 1},
+		{"relative", `{"openapi": "3.0.3", "servers": [{"url": "/api"}]}`,
+			"", "/api", "", 0},
+	} {
+		t.Run(test.name, func(t *testing.T) {
+			g := &Generator{specBytes: []byte(test.spec), openAPI3: isOpenAPI3([]byte(test.spec)), report: &Report{}}
+			server := g.specServer()
+			if server.host != test.host || server.basePath != test.basePath || server.endpoint() != test.endpoint {
+				t.Errorf("unexpected host %q, base path %q and endpoint %q", server.host, server.basePath, server.endpoint())
+			}
+			if warnings := g.report.Count(SeverityWarning); warnings != test.warnings {
+				t.Errorf("expected %d warnings, got %d", test.warnings, warnings)
+			}
+		})
+	}
+}
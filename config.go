@@ -0,0 +1,195 @@
+package swaggerlt
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+)
+
+// ConfigFileNames are the names searched for when no config file is given explicitly.
+var ConfigFileNames = []string{"swaggerlt.yaml", "swaggerlt.yml", "swaggerlt.json"}
+
+// Config describes one or more generation targets and is loaded from a yaml or json file.
+// Spec and output paths are relative to the config file, which is expected in the root directory of module:
+// outputs without an importPath get the import path of module joined with the output directory.
+//
+//	module: github.com/you/project
+//	targets:
+//	  - spec: specs/smapi.json
+//	    paths: ^/v1
+//	    service: smapi
+//	    output: internal/gen
+//	    typeOverrides:
+//	      v1.Timestamp: time.Time
+//	    exclude:
+//	      - ^/v1/internal/
+//	    layout: version
+type Config struct {
+	// Module is used for targets that do not set their own module.
+	Module  string    `json:"module,omitempty"`
+	Targets []*Target `json:"targets"`
+
+	dir string
+}
+
+// Target is a single generator run within a Config.
+type Target struct {
+	// Spec is the spec file, relative to the config file.
+	Spec string `json:"spec"`
+	// Paths is the regular expression selecting spec paths, all paths when empty.
+	Paths   string `json:"paths,omitempty"`
+	Service string `json:"service"`
+	Module  string `json:"module,omitempty"`
+	// Output is the directory generated packages are written to, relative to the config file.
+	Output string `json:"output,omitempty"`
+	// ImportPath is the import path of Output, see Options.ImportPath. It is required when the config
+	// file is not in the module root or Output is absolute or outside of the config file directory.
+	ImportPath string `json:"importPath,omitempty"`
+	// TypeOverrides maps definition names to existing go types, see Options.TypeOverrides.
+	TypeOverrides map[string]string `json:"typeOverrides,omitempty"`
+	// Exclude holds regular expressions of paths or operation names to skip.
+	Exclude []string `json:"exclude,omitempty"`
+	// Layout is version, tag, flat or regex:<expression>, see ParseLayout.
+	Layout string `json:"layout,omitempty"`
+	// GroupByTag generates a sub-client per tag, see Options.GroupByTag.
+	GroupByTag bool `json:"groupByTag,omitempty"`
+	// Fakes generates FakeClient for tests, see Options.Fakes.
+	Fakes bool `json:"fakes,omitempty"`
+	// Context adds a context.Context parameter to the operations, see Options.Context.
+	Context bool `json:"context,omitempty"`
+}
+
+// FindConfig returns the first of ConfigFileNames present in dir or an empty string if none exist.
+func FindConfig(dir string) string {
+	for _, name := range ConfigFileNames {
+		path := filepath.Join(dir, name)
+		if _, err := os.Stat(path); err == nil {
+			return path
+		}
+	}
+	return ""
+}
+
+// LoadConfig reads a yaml or json config file.
+func LoadConfig(path string) (config *Config, err error) {
+	var data []byte
+	if data, err = os.ReadFile(path); err != nil {
+		return
+	}
+	if isYaml(path, data) {
+		if data, err = yamlToJson(data); err != nil {
+			return nil, fmt.Errorf("config %s : %w", path, err)
+		}
+	}
+
+	decoder := json.NewDecoder(bytes.NewReader(data))
+	decoder.DisallowUnknownFields()
+	config = &Config{}
+	if err = decoder.Decode(config); err != nil {
+		return nil, fmt.Errorf("config %s : %w", path, err)
+	}
+	if len(config.Targets) == 0 {
+		return nil, fmt.Errorf("config %s : no targets", path)
+	}
+
+	config.dir = filepath.Dir(path)
+	return
+}
+
+// Options returns the generator options of each target in config order.
+func (c *Config) Options() (result []*Options, err error) {
+	for i, t := range c.Targets {
+		var options *Options
+		if options, err = c.targetOptions(t); err != nil {
+			return nil, fmt.Errorf("target %d : %w", i, err)
+		}
+		result = append(result, options)
+	}
+	return
+}
+
+func (c *Config) targetOptions(t *Target) (options *Options, err error) {
+	options = &Options{
+		SpecFile:      t.Spec,
+		ServiceName:   t.Service,
+		ModuleName:    t.Module,
+		TypeOverrides: t.TypeOverrides,
+		GroupByTag:    t.GroupByTag,
+		Fakes:         t.Fakes,
+		Context:       t.Context,
+	}
+	if options.SpecFile == "" || options.ServiceName == "" {
+		return nil, fmt.Errorf("spec and service are required")
+	}
+	if !filepath.IsAbs(options.SpecFile) {
+		options.SpecFile = filepath.Join(c.dir, options.SpecFile)
+	}
+	if options.ModuleName == "" {
+		options.ModuleName = c.Module
+	}
+	if options.ModuleName == "" {
+		return nil, fmt.Errorf("module is required")
+	}
+
+	options.ImportPath = t.ImportPath
+	options.OutputDir = t.Output
+	if options.ImportPath == "" {
+		if err = c.checkModule(options.ModuleName); err != nil {
+			return nil, err
+		}
+		if err = options.checkImportRoot(); err != nil {
+			return nil, err
+		}
+		// resolve the import path before OutputDir is made relative to the config file
+		options.ImportPath = options.importRoot()
+	}
+	if !filepath.IsAbs(options.OutputDir) {
+		options.OutputDir = filepath.Join(c.dir, options.OutputDir)
+	}
+
+	paths := t.Paths
+	if paths == "" {
+		paths = ".*"
+	}
+	if options.PathRegex, err = regexp.Compile(paths); err != nil {
+		return nil, fmt.Errorf("paths : %w", err)
+	}
+
+	for _, exclude := range t.Exclude {
+		var regex *regexp.Regexp
+		if regex, err = regexp.Compile(exclude); err != nil {
+			return nil, fmt.Errorf("exclude : %w", err)
+		}
+		options.Exclude = append(options.Exclude, regex)
+	}
+
+	if options.Layout, err = ParseLayout(t.Layout); err != nil {
+		return nil, err
+	}
+	return
+}
+
+// checkModule returns an error when the directory of the config file has a go.mod of another module than module,
+// as import paths are derived from module assuming the config file is in its root.
+func (c *Config) checkModule(module string) error {
+	data, err := os.ReadFile(filepath.Join(c.dir, "go.mod"))
+	if err != nil {
+		// without go.mod the config file is trusted to be in the module root
+		return nil
+	}
+	for _, line := range strings.Split(string(data), "\n") {
+		fields := strings.Fields(line)
+		if len(fields) < 2 || fields[0] != "module" {
+			continue
+		}
+		if goModule := strings.Trim(fields[1], `"`); goModule != module {
+			return fmt.Errorf("module %s is not the module %s of the config directory, set importPath", module, goModule)
+		}
+		return nil
+	}
+	return nil
+}
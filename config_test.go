@@ -0,0 +1,80 @@
+package swaggerlt
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestImportRoot(t *testing.T) {
+	for _, test := range []struct {
+		name       string
+		outputDir  string
+		importPath string
+		expected   string
+		err        bool
+	}{
+		{"working directory", "", "", "example.com/project", false},
+		{"relative", "internal/gen", "", "example.com/project/internal/gen", false},
+		{"cleaned", "./internal/../gen/", "", "example.com/project/gen", false},
+		{"absolute", "/tmp/gen", "", "", true},
+		{"outside", "../gen", "", "", true},
+		{"parent", "..", "", "", true},
+		{"absolute with import path", "/tmp/gen", "example.com/other/gen", "example.com/other/gen", false},
+	} {
+		t.Run(test.name, func(t *testing.T) {
+			options := &Options{ModuleName: "example.com/project", OutputDir: test.outputDir, ImportPath: test.importPath}
+			err := options.checkImportRoot()
+			if (err != nil) != test.err {
+				t.Fatalf("unexpected error %v", err)
+			}
+			if err == nil && options.importRoot() != test.expected {
+				t.Errorf("expected %s, got %s", test.expected, options.importRoot())
+			}
+		})
+	}
+}
+
+func TestConfigImportPath(t *testing.T) {
+	for _, test := range []struct {
+		name       string
+		goMod      string
+		output     string
+		importPath string
+		expected   string
+		err        string
+	}{
+		{"module root", "module example.com/project\n", "internal/gen", "", "example.com/project/internal/gen", ""},
+		{"without go.mod", "", "gen", "", "example.com/project/gen", ""},
+		{"other module", "module example.com/other\n", "gen", "", "", "not the module example.com/other"},
+		{"other module with import path", "module example.com/other\n", "gen", "example.com/other/gen", "example.com/other/gen", ""},
+		{"outside", "", "../gen", "", "", "outside of the module"},
+	} {
+		t.Run(test.name, func(t *testing.T) {
+			dir := t.TempDir()
+			if test.goMod != "" {
+				if err := os.WriteFile(filepath.Join(dir, "go.mod"), []byte(test.goMod), 0644); err != nil {
+					t.Fatal(err)
+				}
+			}
+			config := &Config{Module: "example.com/project", dir: dir}
+			options, err := config.targetOptions(&Target{Spec: "spec.json", Service: "smapi", Output: test.output, ImportPath: test.importPath})
+			if test.err != "" {
+				if err == nil || !strings.Contains(err.Error(), test.err) {
+					t.Fatalf("expected an error containing %q, got %v", test.err, err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			if options.ImportPath != test.expected {
+				t.Errorf("expected %s, got %s", test.expected, options.ImportPath)
+			}
+			if options.OutputDir != filepath.Join(dir, test.output) {
+				t.Errorf("unexpected output %s", options.OutputDir)
+			}
+		})
+	}
+}
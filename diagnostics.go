@@ -0,0 +1,142 @@
+package swaggerlt
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"sort"
+	"strings"
+	"sync"
+)
+
+type Severity string
+
+const (
+	// SeverityError is used when a spec construct prevents an operation or type from being generated.
+	SeverityError Severity = "error"
+	// SeverityWarning is used when a spec construct is unsupported and generated in a reduced form.
+	SeverityWarning Severity = "warning"
+)
+
+// Diagnostic describes a problem with a spec construct.
+type Diagnostic struct {
+	// Pointer is the json pointer of the spec location, like #/definitions/v0.Error/properties/code
+	Pointer  string   `json:"pointer"`
+	Severity Severity `json:"severity"`
+	Message  string   `json:"message"`
+}
+
+func (d *Diagnostic) String() string {
+	return fmt.Sprintf("%s %s : %s", d.Severity, d.Pointer, d.Message)
+}
+
+// Report collects the diagnostics of a generator run.
+//
+// When the report contains errors it is returned as the error of Generator.Execute and
+// Generator.Validate, use errors.As to access it.
+type Report struct {
+	Diagnostics []*Diagnostic `json:"diagnostics"`
+
+	mutex sync.Mutex
+}
+
+func (r *Report) add(pointer string, severity Severity, message string) {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+	r.Diagnostics = append(r.Diagnostics, &Diagnostic{Pointer: pointer, Severity: severity, Message: message})
+}
+
+// addError adds err as an error, using the pointer of a SpecError when present.
+func (r *Report) addError(err error) {
+	var se *SpecError
+	if errors.As(err, &se) {
+		r.add(se.Pointer, SeverityError, se.Err.Error())
+		return
+	}
+	r.add("", SeverityError, err.Error())
+}
+
+func (r *Report) warnf(pointer, format string, args ...any) {
+	r.add(pointer, SeverityWarning, fmt.Sprintf(format, args...))
+}
+
+// sort orders the diagnostics by pointer so reports do not depend on generation order.
+func (r *Report) sort() {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+	sort.SliceStable(r.Diagnostics, func(i, j int) bool {
+		a, b := r.Diagnostics[i], r.Diagnostics[j]
+		if a.Pointer != b.Pointer {
+			return a.Pointer < b.Pointer
+		}
+		return a.Message < b.Message
+	})
+}
+
+// Count returns the number of diagnostics with the provided severity.
+func (r *Report) Count(severity Severity) (count int) {
+	for _, d := range r.Diagnostics {
+		if d.Severity == severity {
+			count++
+		}
+	}
+	return
+}
+
+func (r *Report) HasErrors() bool {
+	return r.Count(SeverityError) > 0
+}
+
+// errOrNil returns the report when it contains errors, so callers can compare the result to nil.
+func (r *Report) errOrNil() error {
+	if r.HasErrors() {
+		return r
+	}
+	return nil
+}
+
+func (r *Report) Error() string {
+	lines := []string{r.summary()}
+	for _, d := range r.Diagnostics {
+		if d.Severity == SeverityError {
+			lines = append(lines, "\t"+d.String())
+		}
+	}
+	return strings.Join(lines, "\n")
+}
+
+func (r *Report) summary() string {
+	return fmt.Sprintf("%d errors, %d warnings", r.Count(SeverityError), r.Count(SeverityWarning))
+}
+
+// WriteText writes one line per diagnostic followed by a summary.
+func (r *Report) WriteText(w io.Writer) (err error) {
+	for _, d := range r.Diagnostics {
+		if _, err = fmt.Fprintln(w, d.String()); err != nil {
+			return
+		}
+	}
+	_, err = fmt.Fprintln(w, r.summary())
+	return
+}
+
+// MarshalJSON includes the error and warning counts along with the diagnostics.
+func (r *Report) MarshalJSON() ([]byte, error) {
+	diagnostics := r.Diagnostics
+	if diagnostics == nil {
+		diagnostics = []*Diagnostic{}
+	}
+	return json.Marshal(map[string]any{
+		"errors":      r.Count(SeverityError),
+		"warnings":    r.Count(SeverityWarning),
+		"diagnostics": diagnostics,
+	})
+}
+
+// WriteJSON writes the report as an indented json document.
+func (r *Report) WriteJSON(w io.Writer) error {
+	encoder := json.NewEncoder(w)
+	encoder.SetIndent("", "  ")
+	return encoder.Encode(r)
+}
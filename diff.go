@@ -0,0 +1,272 @@
+package swaggerlt
+
+import (
+	"bytes"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+)
+
+// generatedHeader is the first line of every generated file.
+const generatedHeader = "// Automatically generated, do not edit!"
+
+type ChangeKind string
+
+const (
+	ChangeAdded   ChangeKind = "added"
+	ChangeRemoved ChangeKind = "removed"
+	ChangeChanged ChangeKind = "changed"
+)
+
+// FileChange is a difference between the generated files and the files on disk.
+type FileChange struct {
+	Name string     `json:"name"`
+	Kind ChangeKind `json:"kind"`
+	// Diff is the unified diff from the file on disk to the generated file.
+	Diff string `json:"diff"`
+}
+
+// Diff generates into memory and compares the result with the files in Options.OutputDir
+// without writing anything.
+func (g *Generator) Diff() ([]*FileChange, error) {
+	files, err := g.ExecuteFiles()
+	if err != nil {
+		return nil, err
+	}
+	changes, err := DiffFiles(g.Options.OutputDir, files)
+	if err != nil {
+		return nil, err
+	}
+	return g.manifestRemovals(changes, files)
+}
+
+// manifestRemovals adds the files listed in the manifest on disk which would be pruned by Execute.
+func (g *Generator) manifestRemovals(changes []*FileChange, files map[string][]byte) ([]*FileChange, error) {
+	disk := &DiskSink{Dir: g.Options.OutputDir}
+	previous, err := g.readManifest(disk)
+	if err != nil {
+		return nil, err
+	}
+	reported := map[string]bool{}
+	for _, change := range changes {
+		reported[change.Name] = true
+	}
+	for name, hash := range previous.Files {
+		if _, ok := files[name]; ok || reported[name] {
+			continue
+		}
+		var data []byte
+		if data, err = disk.ReadFile(name); err != nil {
+			if os.IsNotExist(err) {
+				continue
+			}
+			return nil, err
+		}
+		if hashContent(data) == hash {
+			changes = append(changes, &FileChange{Name: name, Kind: ChangeRemoved,
+				Diff: unifiedDiff("a/"+name, "/dev/null", data, nil)})
+		}
+	}
+	sort.Slice(changes, func(i, j int) bool { return changes[i].Name < changes[j].Name })
+	return changes, nil
+}
+
+// DiffFiles compares files, keyed by slash separated name, with the files below dir and reports
+// the files that would be added or changed. Files below dir that are not in files are left out,
+// Diff adds the removals from the manifest which are exactly the files Execute would remove.
+func DiffFiles(dir string, files map[string][]byte) (changes []*FileChange, err error) {
+	if dir == "" {
+		dir = "."
+	}
+
+	for name, data := range files {
+		var current []byte
+		if current, err = os.ReadFile(filepath.Join(dir, filepath.FromSlash(name))); err != nil {
+			if !os.IsNotExist(err) {
+				return nil, err
+			}
+			err = nil
+			changes = append(changes, &FileChange{Name: name, Kind: ChangeAdded,
+				Diff: unifiedDiff("/dev/null", "b/"+name, nil, data)})
+			continue
+		}
+		if !bytes.Equal(current, data) {
+			changes = append(changes, &FileChange{Name: name, Kind: ChangeChanged,
+				Diff: unifiedDiff("a/"+name, "b/"+name, current, data)})
+		}
+	}
+
+	sort.Slice(changes, func(i, j int) bool { return changes[i].Name < changes[j].Name })
+	return
+}
+
+const diffContext = 3
+
+type diffLine struct {
+	op   byte
+	text string
+}
+
+// unifiedDiff returns a unified diff of the lines of a and b.
+func unifiedDiff(nameA, nameB string, a, b []byte) string {
+	lines := diffLines(splitLines(a), splitLines(b))
+
+	out := &strings.Builder{}
+	_, _ = fmt.Fprintf(out, "--- %s\n+++ %s\n", nameA, nameB)
+
+	// line numbers in a and b of each entry in lines
+	lineA, lineB := make([]int, len(lines)+1), make([]int, len(lines)+1)
+	for i, l := range lines {
+		lineA[i+1], lineB[i+1] = lineA[i], lineB[i]
+		if l.op != '+' {
+			lineA[i+1]++
+		}
+		if l.op != '-' {
+			lineB[i+1]++
+		}
+	}
+
+	for i := 0; i < len(lines); {
+		if lines[i].op == ' ' {
+			i++
+			continue
+		}
+		// extend the hunk while changes are separated by less than two contexts
+		start := i - diffContext
+		if start < 0 {
+			start = 0
+		}
+		end := i
+		for j := i; j < len(lines); j++ {
+			if lines[j].op != ' ' {
+				end = j + 1
+			} else if j-end >= 2*diffContext {
+				break
+			}
+		}
+		end += diffContext
+		if end > len(lines) {
+			end = len(lines)
+		}
+
+		countA, countB := lineA[end]-lineA[start], lineB[end]-lineB[start]
+		_, _ = fmt.Fprintf(out, "@@ -%s +%s @@\n", hunkRange(lineA[start], countA), hunkRange(lineB[start], countB))
+		for _, l := range lines[start:end] {
+			out.WriteByte(l.op)
+			out.WriteString(l.text)
+			out.WriteByte('\n')
+		}
+		i = end
+	}
+	return out.String()
+}
+
+func hunkRange(start, count int) string {
+	if count == 0 {
+		return fmt.Sprintf("%d,0", start)
+	}
+	return fmt.Sprintf("%d,%d", start+1, count)
+}
+
+func splitLines(data []byte) []string {
+	if len(data) == 0 {
+		return nil
+	}
+	return strings.Split(strings.TrimSuffix(string(data), "\n"), "\n")
+}
+
+// diffLines returns the edit script from a to b. Between the common prefix and suffix the lines are
+// split at the middle snake of Myers' algorithm and diffed recursively, which needs linear space.
+func diffLines(a, b []string) (lines []diffLine) {
+	prefix := 0
+	for prefix < len(a) && prefix < len(b) && a[prefix] == b[prefix] {
+		prefix++
+	}
+	suffix := 0
+	for suffix < len(a)-prefix && suffix < len(b)-prefix && a[len(a)-1-suffix] == b[len(b)-1-suffix] {
+		suffix++
+	}
+
+	for _, text := range a[:prefix] {
+		lines = append(lines, diffLine{' ', text})
+	}
+
+	midA, midB := a[prefix:len(a)-suffix], b[prefix:len(b)-suffix]
+	switch {
+	case len(midA) == 0:
+		for _, text := range midB {
+			lines = append(lines, diffLine{'+', text})
+		}
+	case len(midB) == 0:
+		for _, text := range midA {
+			lines = append(lines, diffLine{'-', text})
+		}
+	default:
+		x, y, u, v := middleSnake(midA, midB)
+		lines = append(lines, diffLines(midA[:x], midB[:y])...)
+		for _, text := range midA[x:u] {
+			lines = append(lines, diffLine{' ', text})
+		}
+		lines = append(lines, diffLines(midA[u:], midB[v:])...)
+	}
+
+	for _, text := range a[len(a)-suffix:] {
+		lines = append(lines, diffLine{' ', text})
+	}
+	return
+}
+
+// middleSnake returns the snake from x, y to u, v in the middle of a shortest edit script from a to b.
+// The forward search runs from the start and the backward search from the end until their paths overlap.
+func middleSnake(a, b []string) (x, y, u, v int) {
+	n, m := len(a), len(b)
+	delta := n - m
+	max := (n + m + 1) / 2
+	offset := max + 1
+	// forward holds the furthest x on each diagonal k = x - y, backward the furthest
+	// distance from the end on each diagonal of the reversed sequences
+	forward := make([]int, 2*offset+1)
+	backward := make([]int, 2*offset+1)
+
+	for d := 0; d <= max; d++ {
+		for k := -d; k <= d; k += 2 {
+			if k == -d || (k != d && forward[offset+k-1] < forward[offset+k+1]) {
+				x = forward[offset+k+1]
+			} else {
+				x = forward[offset+k-1] + 1
+			}
+			y = x - k
+			u, v = x, y
+			for u < n && v < m && a[u] == b[v] {
+				u++
+				v++
+			}
+			forward[offset+k] = u
+			if c := delta - k; delta%2 != 0 && c >= -(d-1) && c <= d-1 && u+backward[offset+c] >= n {
+				return
+			}
+		}
+		for c := -d; c <= d; c += 2 {
+			var rx int
+			if c == -d || (c != d && backward[offset+c-1] < backward[offset+c+1]) {
+				rx = backward[offset+c+1]
+			} else {
+				rx = backward[offset+c-1] + 1
+			}
+			ry := rx - c
+			u, v = n-rx, m-ry
+			for rx < n && ry < m && a[n-1-rx] == b[m-1-ry] {
+				rx++
+				ry++
+			}
+			backward[offset+c] = rx
+			if k := delta - c; delta%2 == 0 && k >= -d && k <= d && forward[offset+k]+rx >= n {
+				x, y = n-rx, m-ry
+				return
+			}
+		}
+	}
+	panic("diff searches did not overlap")
+}
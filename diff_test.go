@@ -0,0 +1,131 @@
+package swaggerlt
+
+import (
+	"fmt"
+	"math/rand"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+	"testing"
+)
+
+func TestDiffRemovalsFollowManifest(t *testing.T) {
+	g := testGenerator(t, testSpec)
+	if err := g.Execute(); err != nil {
+		t.Fatal(err)
+	}
+	dir := g.Options.OutputDir
+
+	// a file with the generated header that is not in the manifest is never pruned
+	unlisted := filepath.Join(dir, "smapiv0", "old.go")
+	if err := os.WriteFile(unlisted, []byte(generatedHeader+"\n\npackage smapiv0\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	changes, err := g.Diff()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(changes) != 0 {
+		t.Fatalf("expected no changes, got %s %s", changes[0].Kind, changes[0].Name)
+	}
+
+	// a file of the manifest that is no longer generated is reported and then pruned
+	g.Options.Exclude = append(g.Options.Exclude, regexp.MustCompile("createUser"))
+	if changes, err = g.Diff(); err != nil {
+		t.Fatal(err)
+	}
+	removed := map[string]bool{}
+	for _, change := range changes {
+		if change.Kind == ChangeRemoved {
+			removed[change.Name] = true
+		}
+	}
+	if len(removed) != 1 || !removed["smapiv0/client/createUser.go"] {
+		t.Fatalf("expected smapiv0/client/createUser.go to be removed, got %v", removed)
+	}
+	if err = g.Execute(); err != nil {
+		t.Fatal(err)
+	}
+	if _, err = os.Stat(filepath.Join(dir, "smapiv0", "client", "createUser.go")); !os.IsNotExist(err) {
+		t.Errorf("createUser.go was not pruned : %v", err)
+	}
+	if _, err = os.Stat(unlisted); err != nil {
+		t.Errorf("old.go was pruned : %v", err)
+	}
+	if changes, err = g.Diff(); err != nil || len(changes) != 0 {
+		t.Errorf("expected no changes after generating, got %d %v", len(changes), err)
+	}
+}
+
+func TestDiffLines(t *testing.T) {
+	random := rand.New(rand.NewSource(1))
+	lines := func(n int) (lines []string) {
+		for i := 0; i < n; i++ {
+			lines = append(lines, string(rune('a'+random.Intn(4))))
+		}
+		return
+	}
+	for i := 0; i < 500; i++ {
+		a, b := lines(random.Intn(12)), lines(random.Intn(12))
+		var gotA, gotB []string
+		edits := 0
+		for _, line := range diffLines(a, b) {
+			if line.op != '+' {
+				gotA = append(gotA, line.text)
+			}
+			if line.op != '-' {
+				gotB = append(gotB, line.text)
+			}
+			if line.op != ' ' {
+				edits++
+			}
+		}
+		if strings.Join(gotA, "") != strings.Join(a, "") || strings.Join(gotB, "") != strings.Join(b, "") {
+			t.Fatalf("%v to %v : the script does not turn a into b", a, b)
+		}
+		if expected := len(a) + len(b) - 2*lcsLength(a, b); edits != expected {
+			t.Fatalf("%v to %v : expected %d edits, got %d", a, b, expected, edits)
+		}
+	}
+}
+
+func TestDiffLinesLarge(t *testing.T) {
+	var a, b []string
+	for i := 0; i < 50000; i++ {
+		a = append(a, fmt.Sprintf("line %d", i))
+		b = append(b, fmt.Sprintf("line %d", i))
+		if i%1000 == 0 {
+			b[i] = "changed"
+		}
+	}
+	edits := 0
+	for _, line := range diffLines(a, b) {
+		if line.op != ' ' {
+			edits++
+		}
+	}
+	if edits != 100 {
+		t.Errorf("expected 100 edits, got %d", edits)
+	}
+}
+
+// lcsLength returns the length of the longest common subsequence of a and b.
+func lcsLength(a, b []string) int {
+	lcs := make([][]int, len(a)+1)
+	for i := range lcs {
+		lcs[i] = make([]int, len(b)+1)
+	}
+	for i := len(a) - 1; i >= 0; i-- {
+		for j := len(b) - 1; j >= 0; j-- {
+			if a[i] == b[j] {
+				lcs[i][j] = lcs[i+1][j+1] + 1
+			} else if lcs[i+1][j] >= lcs[i][j+1] {
+				lcs[i][j] = lcs[i+1][j]
+			} else {
+				lcs[i][j] = lcs[i][j+1]
+			}
+		}
+	}
+	return lcs[0][0]
+}
@@ -0,0 +1,50 @@
+package swaggerlt
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+)
+
+var errEmptyName = errors.New("empty name can not be converted to a go identifier")
+
+// SpecError is a generation failure at a location in the spec.
+type SpecError struct {
+	// Pointer is the json pointer of the spec location, like #/definitions/v0.Error/properties/code
+	Pointer string
+	Err     error
+}
+
+func (e *SpecError) Error() string {
+	return fmt.Sprintf("%s : %s", e.Pointer, e.Err)
+}
+
+func (e *SpecError) Unwrap() error {
+	return e.Err
+}
+
+// specError wraps err with the spec location unless it already carries one.
+func specError(pointer string, err error) error {
+	if err == nil {
+		return nil
+	}
+	var se *SpecError
+	if errors.As(err, &se) {
+		return err
+	}
+	return &SpecError{Pointer: pointer, Err: err}
+}
+
+// jsonPointer returns a json pointer to a spec location, escaping each key.
+func jsonPointer(keys ...string) string {
+	escaped := make([]string, len(keys))
+	for i, key := range keys {
+		escaped[i] = pointerKey(key)
+	}
+	return "#/" + strings.Join(escaped, "/")
+}
+
+// pointerKey escapes a single key of a json pointer.
+func pointerKey(key string) string {
+	return strings.NewReplacer("~", "~0", "/", "~1").Replace(key)
+}
@@ -0,0 +1,113 @@
+package swaggerlt
+
+import (
+	"fmt"
+	"github.com/dave/jennifer/jen"
+	"path"
+	"sort"
+	"strings"
+)
+
+// createFakeFile renders FakeClient and a fake per sub-client. The fakes implement the interfaces of api.go,
+// record the arguments of each call and return the values set with the <Operation>Returns methods.
+func (g *Generator) createFakeFile(name string, ops []*Operation) error {
+	nfp := g.clientPackage(name)
+	f := jen.NewFilePath(nfp)
+
+	tags, _ := g.clientTags(nfp, ops)
+
+	addFake := func(typeName, tag string, subTags []string) {
+		fakeName := "Fake" + typeName
+		apiName := strings.TrimSuffix(typeName, "Client") + "API"
+		methods := g.methods[nfp][tag]
+		sort.Slice(methods, func(i, j int) bool { return methods[i].name < methods[j].name })
+
+		fields := []jen.Code{jen.Id("mutex").Qual("sync", "Mutex")}
+		for _, m := range methods {
+			for _, im := range m.imports {
+				f.ImportAlias(im, path.Base(im)+"_")
+			}
+			field := lowerFirst(m.name)
+			fields = append(fields, jen.Id(field+"Calls").Op("[]").Id(fakeName+m.name+"Call"))
+			if m.response != nil {
+				fields = append(fields, jen.Id(field+"Response").Add(m.response))
+			}
+			fields = append(fields, jen.Id(field+"Err").Error())
+		}
+		for _, subTag := range subTags {
+			fields = append(fields, jen.Id("fake"+subTag).Op("*").Id("Fake"+subTag+"Client"))
+		}
+
+		f.Comment(fmt.Sprintf("%s is an in-memory %s for tests. It records the arguments of each call and returns\n"+
+			"the values set with the Returns methods, zero values until they are set.", fakeName, apiName))
+		f.Type().Id(fakeName).Struct(fields...)
+		f.Var().Id("_").Id(apiName).Op("=").Parens(jen.Op("*").Id(fakeName)).Parens(jen.Nil())
+
+		receiver := func() *jen.Statement { return jen.Id("fake").Op("*").Id(fakeName) }
+		lock := []jen.Code{
+			jen.Id("fake").Dot("mutex").Dot("Lock").Call(),
+			jen.Defer().Id("fake").Dot("mutex").Dot("Unlock").Call(),
+		}
+
+		for _, m := range methods {
+			field := lowerFirst(m.name)
+			callName := fakeName + m.name + "Call"
+
+			var callFields []jen.Code
+			callValues := jen.Dict{}
+			for _, p := range m.params {
+				callFields = append(callFields, jen.Id(upperFirst(p.name)).Add(p.typ))
+				callValues[jen.Id(upperFirst(p.name))] = jen.Id(p.name)
+			}
+			f.Comment(fmt.Sprintf("%s holds the arguments of a %s call.", callName, m.name))
+			f.Type().Id(callName).Struct(callFields...)
+
+			var returns, returnParams, assign []jen.Code
+			if m.response != nil {
+				returns = append(returns, jen.Id("fake").Dot(field+"Response"))
+				returnParams = append(returnParams, jen.Id("response").Add(m.response))
+				assign = append(assign, jen.Id("fake").Dot(field+"Response").Op("=").Id("response"))
+			}
+			returns = append(returns, jen.Id("fake").Dot(field+"Err"))
+			returnParams = append(returnParams, jen.Err().Error())
+			assign = append(assign, jen.Id("fake").Dot(field+"Err").Op("=").Err())
+
+			params, results := m.signature()
+			f.Comment(fmt.Sprintf("%s records the call and returns the values set with %sReturns.", m.name, m.name))
+			f.Func().Params(receiver()).Id(m.name).Params(params...).Params(results...).Block(append(lock,
+				jen.Id("fake").Dot(field+"Calls").Op("=").Append(jen.Id("fake").Dot(field+"Calls"), jen.Id(callName).Values(callValues)),
+				jen.Return(returns...),
+			)...)
+
+			f.Comment(fmt.Sprintf("%sReturns sets the values returned by %s.", m.name, m.name))
+			f.Func().Params(receiver()).Id(m.name + "Returns").Params(returnParams...).Block(append(lock, assign...)...)
+
+			f.Comment(fmt.Sprintf("%sCalls returns the arguments of the %s calls so far.", m.name, m.name))
+			f.Func().Params(receiver()).Id(m.name + "Calls").Params().Index().Id(callName).Block(append(lock,
+				jen.Return(jen.Append(jen.Index().Id(callName).Parens(jen.Nil()), jen.Id("fake").Dot(field+"Calls").Op("..."))),
+			)...)
+		}
+
+		for _, subTag := range subTags {
+			subFake := "Fake" + subTag + "Client"
+			f.Comment(fmt.Sprintf("%s returns the fake of the %s operations.", subTag, subTag))
+			f.Func().Params(receiver()).Id(subTag).Params().Id(subTag + "API").Block(
+				jen.Return(jen.Id("fake").Dot("Fake" + subTag).Call()),
+			)
+			f.Comment(fmt.Sprintf("Fake%s returns the %s used by %s.", subTag, subFake, subTag))
+			f.Func().Params(receiver()).Id("Fake" + subTag).Params().Op("*").Id(subFake).Block(append(lock,
+				jen.If(jen.Id("fake").Dot("fake"+subTag).Op("==").Nil()).Block(
+					jen.Id("fake").Dot("fake"+subTag).Op("=").Op("&").Id(subFake).Values(),
+				),
+				jen.Return(jen.Id("fake").Dot("fake"+subTag)),
+			)...)
+		}
+	}
+
+	addFake("Client", "", tags)
+	for _, tag := range tags {
+		addFake(tag+"Client", tag, nil)
+	}
+
+	return g.render(g.outputName(nfp, "fake.go"), f)
+}
@@ -1,14 +1,20 @@
 package swaggerlt
 
 import (
+	"bytes"
 	"encoding/json"
 	"fmt"
 	jp "github.com/buger/jsonparser"
 	"github.com/dave/jennifer/jen"
+	"go/token"
 	"log"
 	"os"
+	"path"
 	"path/filepath"
 	"regexp"
+	"runtime"
+	"sort"
+	"strconv"
 	"strings"
 	"sync"
 )
@@ -18,333 +24,537 @@ type Options struct {
 	SpecFile    string
 	ServiceName string
 	ModuleName  string
+	// OutputDir is the directory generated packages are written to, the working directory when empty.
+	OutputDir string
+	// ImportPath is the import path of OutputDir. When empty a relative OutputDir is joined to ModuleName,
+	// so generating into internal/gen/smapi from the module root needs no further configuration.
+	// It is required when OutputDir is absolute or outside of the working directory.
+	ImportPath string
+	// Verbose logs each generated file
+	Verbose bool
+	// TypeOverrides maps definition names like v0.Timestamp, or full refs, to existing go types
+	// like time.Time or github.com/you/project/types.Timestamp which are used instead of generating them.
+	TypeOverrides map[string]string
+	// Exclude skips paths or operation names matching any of the expressions.
+	Exclude []*regexp.Regexp
+	// Workers limits the number of types generated in parallel, runtime.NumCPU when zero.
+	Workers int
+	// Sink receives the generated files, a DiskSink for OutputDir when nil.
+	Sink FileSink
+	// Layout decides the client package of each operation, VersionLayout when nil.
+	Layout Layout
+	// GroupByTag generates the operations as methods of a sub-client per first tag, like client.Users().Get().
+	// Operations without tags stay on Client.
+	GroupByTag bool
+	// Fakes generates FakeClient, an in-memory implementation of API for tests, in fake.go.
+	Fakes bool
+	// Context adds a context.Context as the first parameter of the operation methods, used for the request.
+	Context bool
 }
 
 func New(options *Options) (*Generator, error) {
+	if err := options.checkImportRoot(); err != nil {
+		return nil, err
+	}
 	specBytes, err := os.ReadFile(options.SpecFile)
 	if err != nil {
 		return nil, err
 	}
+	if isYaml(options.SpecFile, specBytes) {
+		if specBytes, err = yamlToJson(specBytes); err != nil {
+			return nil, fmt.Errorf("converting yaml spec %s : %w", options.SpecFile, err)
+		}
+	}
+	sink := options.Sink
+	if sink == nil {
+		sink = &DiskSink{Dir: options.OutputDir}
+	}
 	result := &Generator{
-		Options:      options,
-		specBytes:    specBytes,
-		refGroup:     &sync.WaitGroup{},
-		refManager:   make(chan string, 100),
-		refChan:      make(chan string, 100),
-		refCompleted: map[string]bool{},
+		Options:   options,
+		specBytes: specBytes,
+		openAPI3:  isOpenAPI3(specBytes),
+		sink:      sink,
 	}
+	result.reset()
 	return result, nil
 }
 
 type Generator struct {
 	Options   *Options
 	specBytes []byte
+	openAPI3  bool
 
-	refGroup     *sync.WaitGroup
-	refManager   chan string
-	refChan      chan string
-	refCompleted map[string]bool
+	sink FileSink
+	refs *refGraph
+	// methods holds the generated operation methods by client package and sub-client
+	methods map[string]map[string][]*apiMethod
+
+	schemes     map[string]*securityScheme
+	schemesOnce sync.Once
+	server      *specServer
+
+	report *Report
 }
 
-func (g *Generator) manager() {
-	for ref := range g.refManager {
-		if g.refCompleted[ref] {
-			// work has already been completed
-			g.refGroup.Done()
-			continue
-		}
-		// delegate to workers
-		g.refCompleted[ref] = true
-		g.refChan <- ref
+func (g *Generator) logf(format string, args ...any) {
+	if g.Options.Verbose {
+		log.Printf(format, args...)
 	}
 }
 
-func (g *Generator) refWorker() {
-	for ref := range g.refChan {
+func (g *Generator) generateType(ref string) (err error) {
 
-		// TODO: simplify and break up this long method
+	// TODO: simplify and break up this long method
 
-		path, name := g.refPathAndType(ref)
+	path, name, err := g.refPathAndType(ref)
+	if err != nil {
+		return
+	}
 
-		jc := jen.NewFilePath(path)
+	jc := jen.NewFilePath(path)
 
-		outputFile := filepath.Join(
-			strings.TrimPrefix(path, g.Options.ModuleName+"/"),
-			fmt.Sprintf("%s.go", toGoNameLower(name)),
-		)
-		err := os.MkdirAll(filepath.Dir(outputFile), 0755)
-		if err != nil {
-			log.Fatal(err)
+	outputFile := g.outputName(path, goFileName(name))
+	writeOutputFile := func() error {
+		return g.render(outputFile, jc)
+	}
+
+	value, _, _, err := jp.Get(g.specBytes, refKeys(ref)...)
+	if err != nil {
+		return fmt.Errorf("unable to resolve reference : %w", err)
+	}
+
+	if refDesc, _ := jp.GetString(value, "description"); refDesc != "" {
+		jc.Comment(fmt.Sprintf("%s %s\n", name, refDesc))
+	}
+
+	if _, _, _, enumErr := jp.Get(value, "enum"); enumErr == nil {
+		// this is an enum type
+		enumType, _ := schemaType(value)
+		switch enumType {
+		case "string", "object":
+		case "integer", "number":
+			g.report.warnf(ref+"/enum", "enum values of type %q are not generated", enumType)
+			jc.Type().Id(name).Int()
+			return writeOutputFile()
+		default:
+			return fmt.Errorf("unsupported enum type %q", enumType)
 		}
 
-		writeOutputFile := func() {
-			create, err := os.Create(outputFile)
+		jc.Type().Id(name).String()
+
+		index := 0
+		_, _ = jp.ArrayEach(value, func(value []byte, dataType jp.ValueType, offset int, _ error) {
 			if err != nil {
-				log.Fatal(err)
+				return
 			}
-			err = jc.Render(create)
-			if err != nil {
-				log.Fatal(err)
+			enumValue := string(value)
+			var fn string
+			if fn, err = toGoNameUpper(enumValue); err != nil {
+				g.report.warnf(fmt.Sprintf("%s/enum/%d", ref, index), "enum value %q skipped : %s", enumValue, err)
+				err = nil
+				index++
+				return
 			}
-			_ = create.Close()
-		}
-
-		parts := strings.Split(strings.TrimPrefix(ref, "#/"), "/")
-		value, _, _, err := jp.Get(g.specBytes, parts...)
+			jc.Func().Id(name + "_" + fn).Params().Params(jen.Id(name)).Block(jen.Return(jen.Lit(enumValue)))
+			//jc.Func().Params(jen.Id(name)).Id(fn).Params().Id(name).Block(jen.Return(jen.Lit(enumValue)))
+			index++
+		}, "enum")
 		if err != nil {
-			// TODO: better error handling
-			log.Fatal(err)
-		}
-
-		if refDesc, _ := jp.GetString(value, "description"); refDesc != "" {
-			jc.Comment(fmt.Sprintf("%s %s\n", name, refDesc))
+			return
 		}
 
-		_, _, _, err = jp.Get(value, "enum")
-		if err == nil {
-			// this is an enum type
-			enumType, _ := jp.GetString(value, "type")
-			if enumType != "string" && enumType != "object" {
-				log.Fatal(fmt.Errorf("unsupported enum type %q", enumType))
-			}
+		return writeOutputFile()
+	}
 
-			jc.Type().Id(name).String()
+	var structCode []jen.Code
 
-			_, err = jp.ArrayEach(value, func(value []byte, dataType jp.ValueType, offset int, err error) {
-				enumValue := string(value)
-				fn := toGoNameUpper(enumValue)
-				jc.Func().Id(name + "_" + fn).Params().Params(jen.Id(name)).Block(jen.Return(jen.Lit(enumValue)))
-				//jc.Func().Params(jen.Id(name)).Id(fn).Params().Id(name).Block(jen.Return(jen.Lit(enumValue)))
-			}, "enum")
-			if err != nil {
-				log.Fatal(err)
+	// one simple trick
+	var newValue []byte
+	// error is ignored here as allOf may not be present
+	index := 0
+	_, _ = jp.ArrayEach(value, func(value []byte, dataType jp.ValueType, offset int, _ error) {
+		if err != nil {
+			return
+		}
+		refVal, _ := jp.GetString(value, "$ref")
+		if refVal != "" {
+			var embedded *jen.Statement
+			if embedded, err = g.qualify(&jen.Statement{}, refVal); err != nil {
+				err = specError(fmt.Sprintf("%s/allOf/%d", ref, index), err)
+				return
 			}
-
-			writeOutputFile()
-			g.refGroup.Done()
-			continue
+			structCode = append(structCode, embedded)
+		} else {
+			newValue = value
 		}
+		index++
+	}, "allOf")
+	if err != nil {
+		return
+	}
 
-		var structCode []jen.Code
+	if newValue != nil {
+		value = newValue
+	}
 
-		// one simple trick
-		var newValue []byte
-		// error is ignored here as allOf may not be present
-		_, _ = jp.ArrayEach(value, func(value []byte, dataType jp.ValueType, offset int, err error) {
-			refVal, _ := jp.GetString(value, "$ref")
-			if refVal != "" {
-				structCode = append(structCode, g.qualify(&jen.Statement{}, refVal))
-			} else {
-				newValue = value
+	refType, _ := schemaType(value)
+	baseType, _ := jp.GetBoolean(value, "x-baseType")
+	if baseType {
+		refType = "baseType"
+	}
+	switch refType {
+	case "baseType":
+		// no properties
+	case "object":
+		// each property
+		var propCode []jen.Code
+		// if we have properties they must work
+		if _, _, _, err = jp.Get(value, "properties"); err == nil {
+			if propCode, err = g.propertiesCode(value, ref); err != nil {
+				return
 			}
-		}, "allOf")
-
-		if newValue != nil {
-			value = newValue
-		}
-
-		refType, _ := jp.GetString(value, "type")
-		baseType, _ := jp.GetBoolean(value, "x-baseType")
-		if baseType {
-			refType = "baseType"
-		}
-		switch refType {
-		case "baseType":
-			// no properties
-		case "object":
-			// each property
-			var propCode []jen.Code
-			// if we have properties they must work
-			if _, _, _, err = jp.Get(value, "properties"); err == nil {
-				if propCode, err = g.propertiesCode(value, ref); err != nil {
-					log.Fatalf("properties found but failed to return nil err : %s", err)
-				}
-				structCode = append(structCode, propCode...)
+			structCode = append(structCode, propCode...)
+		} else {
+			// properties not found try for example
+			if _, _, _, err = jp.Get(value, "example"); err == nil {
+				structCode = append(structCode, jen.Comment("TODO: support serialization of examples"))
 			} else {
-				// properties not found try for example
-				if _, _, _, err = jp.Get(value, "example"); err == nil {
-					structCode = append(structCode, jen.Comment("TODO: support serialization of examples"))
-				} else {
-					structCode = append(structCode, jen.Comment("TODO: support serialization of empty object types"))
-				}
+				structCode = append(structCode, jen.Comment("TODO: support serialization of empty object types"))
 			}
 		}
+	}
 
-		jc.Type().Id(name).Struct(structCode...)
-		jc.Comment(fmtJson(value))
+	jc.Type().Id(name).Struct(structCode...)
+	jc.Comment(fmtJson(value))
 
-		writeOutputFile()
-		g.refGroup.Done()
-	}
+	return writeOutputFile()
 }
 
 func (g *Generator) propertiesCode(value []byte, ref string) (propCode []jen.Code, err error) {
 	propCode = []jen.Code{}
 	err = jp.ObjectEach(value, func(key []byte, value []byte, dataType jp.ValueType, offset int) error {
 		propName := string(key)
-		propGoName := toGoNameUpper(propName)
-		propRef, _ := jp.GetString(value, "$ref")
-		propType, _ := jp.GetString(value, "type")
-		propFormat, _ := jp.GetString(value, "format")
-		propDesc, _ := jp.GetString(value, "description")
+		pointer := ref + "/properties/" + pointerKey(propName)
+		return specError(pointer, g.propertyCode(&propCode, propName, value, pointer))
+	}, "properties")
+	return
+}
 
-		_, _, _, additionalPropertiesErr := jp.Get(value, "additionalProperties")
-		if additionalPropertiesErr == nil {
-			propType = "additionalProperties"
-		}
+// propertyCode appends the struct field for a property. Unsupported property schemas are
+// reported as warnings and generated as any so the rest of the type is still usable.
+func (g *Generator) propertyCode(propCode *[]jen.Code, propName string, value []byte, pointer string) (err error) {
+	propGoName, err := toGoNameUpper(propName)
+	if err != nil {
+		return err
+	}
+	propRef, _ := jp.GetString(value, "$ref")
+	propType, nullable := schemaType(value)
+	propFormat, _ := jp.GetString(value, "format")
+	propDesc, _ := jp.GetString(value, "description")
+
+	_, _, _, additionalPropertiesErr := jp.Get(value, "additionalProperties")
+	if additionalPropertiesErr == nil {
+		propType = "additionalProperties"
+	}
 
-		if propRef != "" {
-			propType = "ref"
-		}
+	if propRef != "" {
+		propType = "ref"
+	}
 
-		if propDesc != "" {
-			propCode = append(propCode, jen.Comment(propDesc))
-		}
+	if propDesc != "" {
+		*propCode = append(*propCode, jen.Comment(propDesc))
+	}
 
-		field := jen.Id(propGoName)
+	field := jen.Id(propGoName)
+	unsupported := func(format string, args ...any) {
+		g.report.warnf(pointer, format+", generated as any", args...)
+		field.Any()
+	}
 
+	if nullable {
 		switch propType {
-		case "string":
-			if propFormat == "date-time" {
-				field.Qual("time", "Time")
-			} else {
-				field.String()
+		case "string", "boolean", "integer", "number":
+			// a pointer tells null apart from the zero value
+			field.Op("*")
+		}
+	}
+
+	switch propType {
+	case "string":
+		if propFormat == "date-time" {
+			field.Qual("time", "Time")
+		} else {
+			field.String()
+		}
+	case "boolean":
+		field.Bool()
+	case "integer", "number":
+		field.Int()
+	case "ref":
+		if _, err = g.qualify(field.Op("*"), propRef); err != nil {
+			return err
+		}
+	case "array":
+		itemsType, _ := schemaType(value, "items")
+		switch itemsType {
+		case "":
+			itemsRef, _ := jp.GetString(value, "items", "$ref")
+			if itemsRef == "" {
+				field.Op("[]")
+				unsupported("array items without type or $ref")
+				break
 			}
-		case "boolean":
-			field.Bool()
-		case "integer", "number":
-			field.Int()
-		case "ref":
-			g.qualify(field.Op("*"), propRef)
-		case "array":
-			propType, _ = jp.GetString(value, "items", "type")
-			if propType == "" {
-				propRef, _ = jp.GetString(value, "items", "$ref")
-				g.qualify(field.Op("[]*"), propRef)
-			} else {
-				switch propType {
-				case "string":
-					field.Op("[]").String()
-				case "number":
-					field.Op("[]").Int()
-				case "object":
-					var itemsPropRef string
-					if itemsPropRef, err = jp.GetString(value, "items", "additionalProperties", "$ref"); err == nil {
-						g.qualify(field.Map(jen.String()), itemsPropRef)
-						break
-					}
-					fmt.Println(fmtJson(value))
-					panic(fmt.Errorf("unhandled prop object type %s", propType))
-				default:
-					fmt.Println(fmtJson(value))
-					panic(fmt.Errorf("unhandled prop array type %s", propType))
-				}
+			_, err = g.qualify(field.Op("[]*"), itemsRef)
+		case "string":
+			field.Op("[]").String()
+		case "number", "integer":
+			field.Op("[]").Int()
+		case "object":
+			if itemsRef, _ := jp.GetString(value, "items", "additionalProperties", "$ref"); itemsRef != "" {
+				_, err = g.qualify(field.Op("[]").Map(jen.String()), itemsRef)
+				break
 			}
-		case "additionalProperties":
-			var mapValueType string
-			if mapValueType, err = jp.GetString(value, "additionalProperties", "type"); err == nil {
-				switch mapValueType {
-				case "string":
-					field.Map(jen.String()).String()
-				case "object":
-					var addPropsProps []byte
-					if addPropsProps, _, _, err = jp.Get(value, "additionalProperties", "properties"); err == nil {
-						if "{}" == strings.TrimSpace(string(addPropsProps)) {
-							field.Map(jen.String()).Any()
-						} else {
-							fmt.Println(string(addPropsProps))
-							fmt.Println(ref)
-							fmt.Println(fmtJson(value))
-							return fmt.Errorf("additionalProperties case object/properties: fix additionalProperties/type missing case %s", mapValueType)
-						}
-
-					} else {
-						fmt.Println(ref)
-						fmt.Println(fmtJson(value))
-						return fmt.Errorf("additionalProperties case object: fix additionalProperties/type missing case %s", mapValueType)
-					}
-				default:
-					fmt.Println(ref)
-					fmt.Println(fmtJson(value))
-					return fmt.Errorf("additionalProperties case: fix additionalProperties/type missing case %s", mapValueType)
-				}
-			} else {
-				var mapValueRefType string
-				mapValueRefType, err = jp.GetString(value, "additionalProperties", "$ref")
-				if err == nil {
-					g.qualify(field.Map(jen.String()), mapValueRefType)
-					break
-				}
-				fmt.Println(fmtJson(value))
-				return fmt.Errorf("additionalProperties case: fix propType %s with %s", propType, ref)
+			field.Op("[]")
+			unsupported("array of inline objects")
+		default:
+			field.Op("[]")
+			unsupported("array items of type %q", itemsType)
+		}
+	case "additionalProperties":
+		mapValueType, _ := schemaType(value, "additionalProperties")
+		switch mapValueType {
+		case "":
+			if mapValueRef, _ := jp.GetString(value, "additionalProperties", "$ref"); mapValueRef != "" {
+				_, err = g.qualify(field.Map(jen.String()), mapValueRef)
+				break
 			}
+			field.Map(jen.String())
+			unsupported("additionalProperties without type or $ref")
+		case "string":
+			field.Map(jen.String()).String()
 		case "object":
-			var addPropsProps []byte
-			if addPropsProps, _, _, err = jp.Get(value, "properties"); err == nil {
-				if "{}" == strings.TrimSpace(string(addPropsProps)) {
-					field.Map(jen.String()).Any()
-				} else {
-					fmt.Println(string(addPropsProps))
-					fmt.Println(ref)
-					fmt.Println(fmtJson(value))
-					return fmt.Errorf("object case properties: fix %q", string(addPropsProps))
-				}
-
-			} else {
-				fmt.Println(fmtJson(value))
-				return fmt.Errorf("object case: fix propType %s with %s", propType, ref)
-
+			addPropsProps, _, _, _ := jp.Get(value, "additionalProperties", "properties")
+			field.Map(jen.String())
+			if !isEmptyObject(addPropsProps) {
+				unsupported("additionalProperties with inline properties")
+				break
 			}
-
+			field.Any()
 		default:
-			fmt.Println(fmtJson(value))
-			return fmt.Errorf("default case: fix propType %s with %s", propType, ref)
+			field.Map(jen.String())
+			unsupported("additionalProperties of type %q", mapValueType)
 		}
-		propCode = append(propCode, field.Tag(map[string]string{"json": propName + ",omitempty"}))
-		return nil
-	}, "properties")
-	return
+	case "object":
+		addPropsProps, _, _, _ := jp.Get(value, "properties")
+		if isEmptyObject(addPropsProps) {
+			field.Map(jen.String()).Any()
+			break
+		}
+		unsupported("inline object properties")
+	default:
+		unsupported("property type %q", propType)
+	}
+	if err != nil {
+		return err
+	}
+	*propCode = append(*propCode, field.Tag(map[string]string{"json": propName + ",omitempty"}))
+	return nil
 }
 
+// isEmptyObject reports if value is missing or an empty json object.
+func isEmptyObject(value []byte) bool {
+	trimmed := strings.TrimSpace(string(value))
+	return trimmed == "" || trimmed == "{}"
+}
+
+// Execute generates the client and type packages. Problems do not stop the generation of
+// unrelated operations and types, they are collected in the Report which is returned when it has errors.
 func (g *Generator) Execute() (err error) {
+	g.reset()
 
-	go g.manager()
+	sink := g.sink
+	recorder := newManifestSink(sink)
+	g.sink = recorder
+	defer func() { g.sink = sink }()
 
-	for i := 0; i < 5; i++ {
-		go g.refWorker()
+	var ops []*Operation
+	if ops, err = g.Operations(); err != nil {
+		g.report.addError(err)
 	}
 
-	var versions []string
-	if versions, err = g.uniqueVersions(); err != nil {
-		return
+	for _, name := range g.packageNames(ops) {
+		if err = g.createClientFile(name, ops); err != nil {
+			return
+		}
+	}
+
+	for _, op := range ops {
+		if err = g.buildOperation(op); err != nil {
+			g.report.addError(err)
+		}
 	}
 
-	for _, version := range versions {
-		if err = g.createClientFile(version); err != nil {
+	for _, name := range g.packageNames(ops) {
+		if err = g.createAPIFile(name, ops); err != nil {
+			return
+		}
+		if !g.Options.Fakes {
+			continue
+		}
+		if err = g.createFakeFile(name, ops); err != nil {
 			return
 		}
 	}
 
-	err = jp.ObjectEach(g.specBytes, g.processPaths, "paths")
+	g.generateTypes()
 
-	g.refGroup.Wait()
-	close(g.refChan)
+	if err = g.writeManifest(sink, recorder.manifest); err != nil {
+		g.report.addError(err)
+	}
 
-	return
+	g.report.sort()
+	return g.report.errOrNil()
 }
 
-func (g *Generator) versionDirectory(version string) string {
-	return filepath.Join(g.Options.ServiceName + version)
+// ExecuteFiles runs Execute with the files kept in memory instead of Options.Sink and returns them
+// keyed by name. The files generated so far are returned along with any error.
+func (g *Generator) ExecuteFiles() (files map[string][]byte, err error) {
+	memory := NewMemorySink()
+	sink := g.sink
+	g.sink = memory
+	defer func() { g.sink = sink }()
+
+	err = g.Execute()
+	return memory.Files(), err
+}
+
+// generateTypes walks the reference graph one level at a time, generating the types of a level
+// with at most Options.Workers goroutines. Refs found while generating form the next level.
+func (g *Generator) generateTypes() {
+	workers := g.Options.Workers
+	if workers < 1 {
+		workers = runtime.NumCPU()
+	}
+	for refs := g.refs.next(); len(refs) > 0; refs = g.refs.next() {
+		jobs := make(chan string)
+		wg := &sync.WaitGroup{}
+		for i := 0; i < workers && i < len(refs); i++ {
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				for ref := range jobs {
+					if err := g.generateType(ref); err != nil {
+						g.report.addError(specError(ref, err))
+					}
+				}
+			}()
+		}
+		for _, ref := range refs {
+			jobs <- ref
+		}
+		close(jobs)
+		wg.Wait()
+	}
+}
+
+// reset clears the state of a previous Execute or Validate so a Generator can be run again,
+// like Diff followed by Execute.
+func (g *Generator) reset() {
+	g.refs = newRefGraph()
+	g.methods = nil
+	g.schemes = nil
+	g.schemesOnce = sync.Once{}
+	g.server = nil
+	g.report = &Report{}
+}
+
+// Report returns the diagnostics, including warnings, of the last Execute or Validate.
+func (g *Generator) Report() *Report {
+	return g.report
+}
+
+// importRoot returns the import path that corresponds to OutputDir, checked by checkImportRoot.
+func (options *Options) importRoot() string {
+	if options.ImportPath != "" {
+		return options.ImportPath
+	}
+	return path.Join(options.ModuleName, filepath.ToSlash(filepath.Clean(options.OutputDir)))
+}
+
+// checkImportRoot returns an error when ImportPath is empty and OutputDir can not be joined to ModuleName,
+// the working directory is assumed to be the module root.
+func (options *Options) checkImportRoot() error {
+	if options.ImportPath != "" {
+		return nil
+	}
+	outputDir := filepath.ToSlash(filepath.Clean(options.OutputDir))
+	if filepath.IsAbs(options.OutputDir) || outputDir == ".." || strings.HasPrefix(outputDir, "../") {
+		return fmt.Errorf("output %s is absolute or outside of the module, the import path is required", options.OutputDir)
+	}
+	return nil
+}
+
+// outputName returns the sink file name for a file in the generated package importPath.
+func (g *Generator) outputName(importPath, fileName string) string {
+	rel := strings.TrimPrefix(strings.TrimPrefix(importPath, g.Options.importRoot()), "/")
+	return path.Join(rel, fileName)
+}
+
+// render formats f with the generated header and writes it to the sink as name.
+func (g *Generator) render(name string, f *jen.File) error {
+	f.HeaderComment(strings.TrimPrefix(generatedHeader, "// "))
+	buf := &bytes.Buffer{}
+	if err := f.Render(buf); err != nil {
+		return fmt.Errorf("render %s : %w", name, err)
+	}
+	g.logf("writing %s", name)
+	return g.sink.WriteFile(name, buf.Bytes())
+}
+
+// clientPackage returns the import path of the client package for a Layout package name.
+func (g *Generator) clientPackage(name string) string {
+	return path.Join(g.Options.importRoot(), g.Options.ServiceName+name, "client")
+}
+
+// operationPackage returns the import path of the client package an operation is generated in.
+func (g *Generator) operationPackage(op *Operation) string {
+	return g.clientPackage(g.layout().Package(op))
+}
+
+// operationTag returns the tag name of the sub-client of op, or an empty string when op is a method of Client.
+func (g *Generator) operationTag(op *Operation) string {
+	if !g.Options.GroupByTag || len(op.Tags) == 0 {
+		return ""
+	}
+	name := tagName(op.Tags[0])
+	if isClientField(name) || name == "New" || (g.Options.Fakes && name == "Fake") {
+		// the accessor would clash with the fields of Client, the sub-client type with NewClient or FakeClient
+		name += "Tag"
+	}
+	return name
+}
+
+func (g *Generator) layout() Layout {
+	if g.Options.Layout == nil {
+		return VersionLayout{}
+	}
+	return g.Options.Layout
 }
 
 func (g *Generator) buildOperation(op *Operation) error {
+	pointer := jsonPointer("paths", op.Path, op.Verb)
+	return specError(pointer, g.buildOperationCode(op, pointer))
+}
+
+func (g *Generator) buildOperationCode(op *Operation, pointer string) (err error) {
 
-	// TODO: handle non versioned paths
-	version := strings.Split(strings.TrimPrefix(op.Path, "/"), "/")[0]
-	packageName := filepath.Join(g.Options.ModuleName, g.Options.ServiceName+version, "client")
+	packageName := g.operationPackage(op)
 	j := jen.NewFilePath(packageName)
 
-	goName := toGoNameUpper(op.XOperationName)
+	goName := op.GoName
+	if len(op.Responses) == 0 {
+		return specError(pointer+"/responses", fmt.Errorf("no responses"))
+	}
 
 	var doc []string
 	doc = append(doc, fmt.Sprintf("%s %s", goName, op.Description))
@@ -355,63 +565,93 @@ func (g *Generator) buildOperation(op *Operation) error {
 
 	j.Comment(strings.Join(doc, "\n"))
 
+	// method holds the signature, the interface uses the same import aliases as the file
+	method := &apiMethod{name: goName, doc: strings.TrimSpace(strings.SplitN(doc[0], "\n", 2)[0])}
+	if g.Options.Context {
+		method.params = append(method.params, &apiParam{name: "ctx", typ: jen.Qual("context", "Context")})
+	}
+
 	// TODO: refactor out to method on Parameter ?
-	var signature []jen.Code
-	for _, p := range op.Parameters {
-		var param *jen.Statement
+	for i, p := range op.Parameters {
+		paramPointer := fmt.Sprintf("%s/parameters/%d", pointer, i)
+		if g.Options.Context && p.Name == "ctx" {
+			return specError(paramPointer, fmt.Errorf("parameter ctx clashes with the context parameter"))
+		}
+		var paramType *jen.Statement
 		switch p.In {
 		case "query", "header", "path":
 			switch p.Type {
 			case "string":
-				param = jen.Id(p.Name).String()
-			case "number":
-				param = jen.Id(p.Name).Int()
+				paramType = jen.String()
+			case "number", "integer":
+				paramType = jen.Int()
 			case "array":
 				switch p.Items {
 				case "string":
-					param = jen.Id(p.Name).Op("[]").String()
-				case "number":
-					param = jen.Id(p.Name).Op("[]").Int()
+					paramType = jen.Op("[]").String()
+				case "number", "integer":
+					paramType = jen.Op("[]").Int()
 				default:
 					if p.ItemsRef != "" {
-						param = g.qualify(jen.Id(p.Name).Op("[]"), p.ItemsRef)
+						if paramType, err = g.qualify(jen.Op("[]"), p.ItemsRef); err != nil {
+							return specError(paramPointer, err)
+						}
 						break
 					}
 
-					fmt.Println(op.Path)
-					panic(fmt.Errorf("unhandled parameter name=%s in=%s type=%s items=%s", p.Name, p.In, p.Type, p.Items))
+					return specError(paramPointer, fmt.Errorf("unhandled parameter name=%s in=%s type=%s items=%s", p.Name, p.In, p.Type, p.Items))
 				}
 
 			}
 		case "body":
-			im, _ := g.refPathAndType(p.Ref)
+			var im string
+			if im, _, err = g.refPathAndType(p.Ref); err != nil {
+				return specError(paramPointer, err)
+			}
 			j.ImportAlias(im, filepath.Base(im)+"_")
-			param = g.qualify(jen.Id(p.Name).Op("*"), p.Ref)
+			method.imports = append(method.imports, im)
+			if paramType, err = g.qualify(jen.Op("*"), p.Ref); err != nil {
+				return specError(paramPointer, err)
+			}
 		}
-		if param != nil {
-			signature = append(signature, param)
+		if paramType != nil {
+			method.params = append(method.params, &apiParam{name: p.Name, typ: paramType})
 			continue
 		}
-		panic(fmt.Errorf("unhandled parameter type in=%s type=%s", p.In, p.Type))
+		return specError(paramPointer, fmt.Errorf("unhandled parameter type in=%s type=%s", p.In, p.Type))
 	}
 
 	// TODO: handle different payloads for error body
-	var result []jen.Code
-	hasResponse := op.Responses[0].Ref != ""
+	success := op.successResponse()
+	if success == nil {
+		g.report.warnf(pointer+"/responses", "no 2xx or default response, successful responses are not decoded")
+		success = &Response{}
+	}
+	hasResponse := success.Ref != ""
+	responsePointer := pointer + "/responses/" + success.key()
 	if hasResponse {
-		im, _ := g.refPathAndType(op.Responses[0].Ref)
+		var im string
+		if im, _, err = g.refPathAndType(success.Ref); err != nil {
+			return specError(responsePointer, err)
+		}
 		j.ImportAlias(im, filepath.Base(im)+"_")
+		method.imports = append(method.imports, im)
 
-		response := jen.Id("response").Op("*")
-		g.qualify(response, op.Responses[0].Ref)
-		result = append(result, response)
+		if method.response, err = g.qualify(jen.Op("*"), success.Ref); err != nil {
+			return specError(responsePointer, err)
+		}
+	}
+
+	// client is the expression of the *Client the request is made with
+	tag := g.operationTag(op)
+	receiverType, client := "Client", func() *jen.Statement { return jen.Id("s") }
+	if tag != "" {
+		receiverType, client = tag+"Client", func() *jen.Statement { return jen.Id("s").Dot("client") }
 	}
-	result = append(result, jen.Err().Error())
 
 	var block []jen.Code
 	block = append(block,
-		jen.Id("h").Op(":=").Qual("github.com/mlctrez/swaggerlt", "NewRequestHelper").
-			Params(jen.Lit(op.Verb), jen.Id("s.Endpoint"), jen.Lit(op.Path)))
+		jen.Id("h").Op(":=").Add(client()).Dot("newRequest").Call(jen.Lit(goName), jen.Lit(op.Verb), jen.Lit(op.Path)))
 
 	for _, p := range op.Parameters {
 		var st *jen.Statement
@@ -428,76 +668,266 @@ func (g *Generator) buildOperation(op *Operation) error {
 		block = append(block, st)
 	}
 
+	if security := g.securityCode(op, pointer); security != nil {
+		block = append(block, security)
+	}
+
 	if hasResponse {
-		block = append(block, g.qualify(jen.Id("response").Op("=").Op("&"), op.Responses[0].Ref).Op("{}"))
+		var response *jen.Statement
+		if response, err = g.qualify(jen.Id("response").Op("=").Op("&"), success.Ref); err != nil {
+			return specError(responsePointer, err)
+		}
+		block = append(block, response.Op("{}"))
 		//fmt.Fprintf(c, "%s = &%s{}\n", resultVar, resultTypeWithPkg)
 		block = append(block, jen.Id("h").Dot("Response").Op("=").Id("response"))
 		//fmt.Fprintf(c, "h.Response = %s\n", resultVar)
 	}
 
-	for i, res := range op.Responses {
-		if i == 0 || res.Code == 0 {
+	for _, res := range op.Responses {
+		if res == success || res.Code < 300 {
+			// default and 2xx responses are never decoded as errors
 			continue
 		}
 		if res.Ref != "" {
+			var responseType *jen.Statement
+			if responseType, err = g.qualify((&jen.Statement{}).Op("&"), res.Ref); err != nil {
+				return specError(pointer+"/responses/"+res.key(), err)
+			}
 			st := jen.Id("h").Dot("ResponseType").
-				Call(jen.Lit(res.Code), g.qualify((&jen.Statement{}).Op("&"), res.Ref).Op("{}"))
+				Call(jen.Lit(res.Code), responseType.Op("{}"))
 			block = append(block, st)
 		}
 	}
 
-	block = append(block, jen.Err().Op("=").Id("h").Dot("Execute").
-		Call(jen.Id("s").Dot("Client")))
+	if g.Options.Context {
+		block = append(block, jen.Err().Op("=").Id("h").Dot("ExecuteContext").
+			Call(jen.Id("ctx"), client().Dot("Client")))
+	} else {
+		block = append(block, jen.Err().Op("=").Id("h").Dot("Execute").
+			Call(client().Dot("Client")))
+	}
 	//fmt.Fprintf(c, "err = h.Execute(s.Client)\n")
 
 	block = append(block, jen.Return())
 
-	receiver := jen.Id("s").Op("*").Id("Client")
+	receiver := jen.Id("s").Op("*").Id(receiverType)
+	signature, result := method.signature()
 	j.Func().Params(receiver).Id(goName).Params(signature...).Params(result...).Block(block...)
+	g.addMethod(packageName, tag, method)
 
 	j.Comment(fmtJson(op.RawData))
 
 	// write out the file
-	goFile := fmt.Sprintf("%s.go", toGoNameLower(op.XOperationName))
-	path := filepath.Join(g.Options.ServiceName+version, "client", goFile)
-	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
-		return err
-	}
-
-	if create, err := os.Create(path); err != nil {
-		return err
-	} else {
-		defer func() { _ = create.Close() }()
-		return j.Render(create)
-	}
-
+	return g.render(g.outputName(packageName, goFileName(goName)), j)
 }
 
-func (g *Generator) processPaths(pathBytes []byte, value []byte, _ jp.ValueType, _ int) error {
+// Operations returns the operations of the paths matching Options.PathRegex in spec order.
+// Operations that can not be read are added to the Report as errors and left out.
+func (g *Generator) Operations() (ops []*Operation, err error) {
+	security := securityRequirements(g.specBytes, "security")
+	err = jp.ObjectEach(g.specBytes, func(pathBytes []byte, value []byte, _ jp.ValueType, _ int) error {
 
-	path := string(pathBytes)
-	regex := g.Options.PathRegex
+		path := string(pathBytes)
+		if !g.Options.PathRegex.MatchString(path) || g.excluded(path) {
+			return nil
+		}
 
-	if regex.MatchString(path) {
+		pathParameters, err := g.pathParameters(path, value)
+		if err != nil {
+			g.report.addError(specError(jsonPointer("paths", path), err))
+			return nil
+		}
 		return jp.ObjectEach(value, func(verbBytes []byte, value []byte, _ jp.ValueType, _ int) (err error) {
 
 			verb := string(verbBytes)
+			if !isVerb(verb) {
+				// path level keys like parameters, summary or servers
+				return
+			}
 			var op *Operation
 
-			if op, err = OperationFromSpec(path, verb, value); err != nil {
-				return
+			if op, err = g.operationFromSpec(path, verb, value); err != nil {
+				g.report.addError(specError(jsonPointer("paths", path, verb), err))
+				return nil
+			}
+			op.inheritParameters(pathParameters)
+			if op.Security == nil {
+				op.Security = security
 			}
 
-			return g.buildOperation(op)
+			if op.XOperationName != "" && g.excluded(op.XOperationName) ||
+				op.OperationID != "" && g.excluded(op.OperationID) {
+				return
+			}
+			ops = append(ops, op)
+			return
 		})
+	}, "paths")
+	if err != nil {
+		return nil, fmt.Errorf("paths : %w", err)
+	}
+	g.nameOperations(ops)
+	return
+}
+
+// nameOperations assigns the GoName of the operations from x-operation-name, operationId or a name
+// synthesized from the verb and path, in that order. When the name is already used in the client
+// package the next candidate is tried before falling back to a numeric suffix, with a warning.
+func (g *Generator) nameOperations(ops []*Operation) {
+	// names are compared lower cased since file names are derived from them
+	used := map[string]map[string]*Operation{}
+	for _, op := range ops {
+		pointer := jsonPointer("paths", op.Path, op.Verb)
+		packageName := g.operationPackage(op)
+		if used[packageName] == nil {
+			// client.go, api.go and fake.go hold the Client type, its fields and the sub-client accessors
+			used[packageName] = map[string]*Operation{"client": nil, "api": nil, "fake": nil}
+			for _, field := range clientFields {
+				used[packageName][strings.ToLower(field)] = nil
+			}
+			for _, other := range ops {
+				if tag := g.operationTag(other); tag != "" && g.operationPackage(other) == packageName {
+					used[packageName][strings.ToLower(tag)] = nil
+					if g.Options.Fakes {
+						used[packageName][strings.ToLower("Fake"+tag)] = nil
+					}
+				}
+			}
+		}
+		names := used[packageName]
+		// the fakes add Returns and Calls methods next to the method of each operation
+		suffixes := []string{""}
+		if g.Options.Fakes {
+			suffixes = append(suffixes, "Returns", "Calls")
+		}
+		available := func(name string) bool {
+			for _, suffix := range suffixes {
+				if _, ok := names[strings.ToLower(name+suffix)]; ok {
+					return false
+				}
+			}
+			return true
+		}
+
+		var candidates []string
+		for _, source := range []struct{ key, name string }{
+			{"x-operation-name", op.XOperationName},
+			{"operationId", op.OperationID},
+		} {
+			if source.name == "" {
+				continue
+			}
+			// x-operation-name is used as is when it is a valid name, operationIds are camel cased
+			name, _ := toGoNameUpper(source.name)
+			if source.key == "operationId" || !token.IsIdentifier(name) {
+				name = operationName(source.name)
+			}
+			if name == "" {
+				g.report.warnf(pointer+"/"+source.key, "%q has no usable characters, name not used", source.name)
+				continue
+			}
+			candidates = append(candidates, name)
+		}
+		candidates = append(candidates, synthesizeName(op.Verb, op.Path))
+
+		op.GoName = ""
+		for _, name := range candidates {
+			if available(name) {
+				op.GoName = name
+				break
+			}
+		}
+		if op.GoName == "" {
+			for i := 2; op.GoName == ""; i++ {
+				name := fmt.Sprintf("%s%d", candidates[0], i)
+				if available(name) {
+					op.GoName = name
+				}
+			}
+		}
+		if op.GoName != candidates[0] {
+			message := fmt.Sprintf("operation name %s is already used", candidates[0])
+			if other := names[strings.ToLower(candidates[0])]; other != nil {
+				message += fmt.Sprintf(" by %s %s", strings.ToUpper(other.Verb), other.Path)
+			}
+			g.report.warnf(pointer, "%s, using %s", message, op.GoName)
+		}
+		for _, suffix := range suffixes {
+			names[strings.ToLower(op.GoName+suffix)] = op
+		}
 	}
-	return nil
 }
 
-func (g *Generator) refPathAndType(ref string) (string, string) {
+func (g *Generator) excluded(name string) bool {
+	for _, regex := range g.Options.Exclude {
+		if regex.MatchString(name) {
+			return true
+		}
+	}
+	return false
+}
+
+// Validate parses every matching operation and checks that the definitions they reference exist.
+// The Report is returned when problems are found.
+func (g *Generator) Validate() error {
+	g.reset()
+	ops, err := g.Operations()
+	if err != nil {
+		g.report.addError(err)
+		return g.report
+	}
+
+	checkRef := func(pointer, ref string) {
+		if ref == "" {
+			return
+		}
+		if _, _, _, err := jp.Get(g.specBytes, refKeys(ref)...); err != nil {
+			g.report.add(pointer, SeverityError, fmt.Sprintf("unresolved reference %s", ref))
+		}
+	}
+
+	for _, op := range ops {
+		pointer := jsonPointer("paths", op.Path, op.Verb)
+		if len(op.Responses) == 0 {
+			g.report.add(pointer, SeverityError, "no responses")
+		}
+		for i, p := range op.Parameters {
+			paramPointer := fmt.Sprintf("%s/parameters/%d", pointer, i)
+			checkRef(paramPointer, p.Ref)
+			checkRef(paramPointer, p.ItemsRef)
+			if p.In == "body" && p.Ref == "" {
+				g.report.add(paramPointer, SeverityError, fmt.Sprintf("body parameter %s has no schema $ref", p.NameOrig))
+			}
+		}
+		for _, r := range op.Responses {
+			checkRef(pointer+"/responses/"+r.key(), r.Ref)
+		}
+	}
+
+	g.report.sort()
+	return g.report.errOrNil()
+}
+
+func (g *Generator) operationFromSpec(path, verb string, value []byte) (*Operation, error) {
+	if g.openAPI3 {
+		return OperationFromOpenAPI3(g.specBytes, path, verb, value)
+	}
+	return OperationFromSpec(path, verb, value)
+}
+
+// pathParameters returns the parameters declared on a path item which apply to all of its operations.
+func (g *Generator) pathParameters(path string, pathItem []byte) ([]*Parameter, error) {
+	pointer := jsonPointer("paths", path)
+	if g.openAPI3 {
+		return parametersFromOpenAPI3(g.specBytes, pathItem, pointer)
+	}
+	return parametersFromSpec(pathItem, pointer)
+}
+
+func (g *Generator) refPathAndType(ref string) (string, string, error) {
 
 	if ref == "" {
-		panic("refPathAndType: ref was empty string")
+		return "", "", fmt.Errorf("refPathAndType: ref was empty string")
 	}
 
 	refParts := strings.Split(ref, "/")
@@ -507,10 +937,13 @@ func (g *Generator) refPathAndType(ref string) (string, string) {
 	refName := refParts[len(refParts)-1]
 	refNameParts := strings.Split(refName, ".")
 
-	pathParts := []string{options.ModuleName}
-	pathParts = append(pathParts, options.ServiceName+refNameParts[0])
+	pathParts := []string{g.Options.importRoot()}
 	if len(refNameParts) > 1 {
+		pathParts = append(pathParts, options.ServiceName+refNameParts[0])
 		pathParts = append(pathParts, refNameParts[1:len(refNameParts)-1]...)
+	} else {
+		// definitions without a version prefix go to the service package
+		pathParts = append(pathParts, options.ServiceName)
 	}
 
 	path := filepath.Join(pathParts...)
@@ -519,19 +952,47 @@ func (g *Generator) refPathAndType(ref string) (string, string) {
 		path += "1"
 	}
 
-	refType := refNameParts[len(refNameParts)-1]
-	refType = toGoNameUpper(refType)
-	return path, refType
+	refType, err := toGoNameUpper(refNameParts[len(refNameParts)-1])
+	if err != nil {
+		return "", "", fmt.Errorf("type name of %s : %w", ref, err)
+	}
+	return path, refType, nil
 }
 
-func (g *Generator) qualify(s *jen.Statement, ref string) *jen.Statement {
+func (g *Generator) qualify(s *jen.Statement, ref string) (*jen.Statement, error) {
+
+	if path, refType, ok := g.typeOverride(ref); ok {
+		if path == "" {
+			return s.Id(refType), nil
+		}
+		return s.Qual(path, refType), nil
+	}
 
-	path, refType := g.refPathAndType(ref)
-	g.refGroup.Add(1)
-	g.refManager <- ref
+	path, refType, err := g.refPathAndType(ref)
+	if err != nil {
+		return s, err
+	}
+	g.refs.add(ref)
 	s.Qual(path, refType)
 
-	return s
+	return s, nil
+}
+
+// typeOverride returns the package path and type name from Options.TypeOverrides for a ref.
+func (g *Generator) typeOverride(ref string) (path, refType string, ok bool) {
+	override, ok := g.Options.TypeOverrides[ref]
+	if !ok {
+		refParts := strings.Split(ref, "/")
+		override, ok = g.Options.TypeOverrides[refParts[len(refParts)-1]]
+	}
+	if !ok {
+		return
+	}
+	if i := strings.LastIndex(override, "."); i > 0 {
+		return override[:i], override[i+1:], true
+	}
+	// builtin types like string
+	return "", override, true
 }
 
 type Parameter struct {
@@ -564,15 +1025,31 @@ type Response struct {
 	Code        int    `json:"code"`
 	Description string `json:"description,omitempty"`
 	Ref         string `json:"ref,omitempty"`
+	// specKey is the key of the response in the spec when it is not the status code, like 2XX
+	specKey string
+}
+
+// key returns the key of the response in the spec, a status code, a range or default.
+func (r *Response) key() string {
+	if r.specKey != "" {
+		return r.specKey
+	}
+	if r.Code == 0 {
+		return "default"
+	}
+	return strconv.Itoa(r.Code)
 }
 
-func (g *Generator) uniqueVersions() (result []string, err error) {
-	err = jp.ObjectEach(g.specBytes, func(key []byte, _ []byte, _ jp.ValueType, _ int) error {
-		path := string(key)
-		if g.Options.PathRegex.MatchString(path) {
-			result = append(result, strings.Split(path, "/")[1])
+// packageNames returns the sorted Layout package names of ops.
+func (g *Generator) packageNames(ops []*Operation) (result []string) {
+	seen := map[string]bool{}
+	for _, op := range ops {
+		name := g.layout().Package(op)
+		if !seen[name] {
+			seen[name] = true
+			result = append(result, name)
 		}
-		return nil
-	}, "paths")
+	}
+	sort.Strings(result)
 	return
 }
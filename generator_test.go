@@ -0,0 +1,259 @@
+package swaggerlt
+
+import (
+	"errors"
+	"io/fs"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"regexp"
+	"strconv"
+	"strings"
+	"testing"
+)
+
+const testSpec = `{
+  "swagger": "2.0",
+  "paths": {
+    "/v0/users/{id}": {
+      "get": {
+        "tags": ["users"],
+        "operationId": "getUser",
+        "parameters": [{"name": "id", "in": "path", "type": "string"}],
+        "responses": {
+          "200": {"schema": {"$ref": "#/definitions/v0.User"}},
+          "404": {"schema": {"$ref": "#/definitions/v0.Error"}}
+        }
+      }
+    },
+    "/v0/users": {
+      "post": {
+        "tags": ["users"],
+        "operationId": "createUser",
+        "parameters": [{"name": "user", "in": "body", "schema": {"$ref": "#/definitions/v0.User"}}],
+        "responses": {"201": {"schema": {"$ref": "#/definitions/v0.User"}}}
+      }
+    }
+  },
+  "definitions": {
+    "v0.User": {"type": "object", "properties": {"name": {"type": "string"}, "pet": {"$ref": "#/definitions/v0.Pet"}}},
+    "v0.Pet": {"type": "object", "properties": {"name": {"type": "string"}}},
+    "v0.Error": {"type": "object", "properties": {"message": {"type": "string"}}}
+  }
+}`
+
+// testGenerator returns a generator for spec writing to a temporary directory.
+func testGenerator(t *testing.T, spec string) *Generator {
+	t.Helper()
+	dir := t.TempDir()
+	specFile := filepath.Join(dir, "spec.json")
+	if err := os.WriteFile(specFile, []byte(spec), 0644); err != nil {
+		t.Fatal(err)
+	}
+	g, err := New(&Options{
+		SpecFile:    specFile,
+		PathRegex:   regexp.MustCompile(".*"),
+		ServiceName: "smapi",
+		ModuleName:  "example.com/gen",
+		OutputDir:   filepath.Join(dir, "out"),
+		ImportPath:  "example.com/gen",
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	return g
+}
+
+func TestOperationsContinueAfterError(t *testing.T) {
+	spec := `{
+  "swagger": "2.0",
+  "paths": {
+    "/v0/broken": {
+      "get": {"parameters": [{"in": "query", "type": "string"}], "responses": {"200": {"description": "ok"}}}
+    },
+    "/v0/users": {
+      "get": {"operationId": "listUsers", "responses": {"200": {"schema": {"$ref": "#/definitions/v0.User"}}}}
+    }
+  },
+  "definitions": {"v0.User": {"type": "object", "properties": {"name": {"type": "string"}}}}
+}`
+	g := testGenerator(t, spec)
+	files, err := g.ExecuteFiles()
+	var report *Report
+	if !errors.As(err, &report) || report.Count(SeverityError) != 1 {
+		t.Fatalf("expected a report with one error, got %v", err)
+	}
+	if pointer := report.Diagnostics[0].Pointer; pointer != "#/paths/~1v0~1broken/get/parameters/0" {
+		t.Errorf("unexpected pointer %s", pointer)
+	}
+	for _, name := range []string{"smapiv0/client/client.go", "smapiv0/client/listUsers.go", "smapiv0/user.go"} {
+		if files[name] == nil {
+			t.Errorf("%s was not generated", name)
+		}
+	}
+}
+
+func TestExecuteTwice(t *testing.T) {
+	g := testGenerator(t, testSpec)
+
+	// diff before generating is the check then regenerate flow
+	if _, err := g.Diff(); err != nil {
+		t.Fatal(err)
+	}
+	if err := g.Execute(); err != nil {
+		t.Fatal(err)
+	}
+	first := readTree(t, g.Options.OutputDir)
+
+	if err := g.Execute(); err != nil {
+		t.Fatal(err)
+	}
+	second := readTree(t, g.Options.OutputDir)
+
+	for _, name := range []string{"smapiv0/client/api.go", "smapiv0/user.go", "smapiv0/pet.go", "smapiv0/error.go"} {
+		if first[name] == "" {
+			t.Errorf("%s was not generated", name)
+		}
+	}
+	if len(first) != len(second) {
+		t.Fatalf("expected %d files on the second run, got %d", len(first), len(second))
+	}
+	for name, data := range first {
+		if second[name] != data {
+			t.Errorf("%s changed on the second run", name)
+		}
+	}
+
+	files, err := g.ExecuteFiles()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(files) != len(first) {
+		t.Errorf("expected %d files from ExecuteFiles, got %d", len(first), len(files))
+	}
+	if changes, err := g.Diff(); err != nil || len(changes) != 0 {
+		t.Errorf("expected no changes, got %d %v", len(changes), err)
+	}
+}
+
+// readTree returns the content of the files below dir by slash separated name.
+func readTree(t *testing.T, dir string) map[string]string {
+	t.Helper()
+	files := map[string]string{}
+	err := filepath.WalkDir(dir, func(path string, d fs.DirEntry, err error) error {
+		if err != nil || d.IsDir() {
+			return err
+		}
+		data, err := os.ReadFile(path)
+		if err != nil {
+			return err
+		}
+		rel, err := filepath.Rel(dir, path)
+		files[filepath.ToSlash(rel)] = string(data)
+		return err
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	return files
+}
+
+func TestNullableProperties(t *testing.T) {
+	spec := `{
+  "openapi": "3.1.0",
+  "paths": {
+    "/v0/users": {
+      "get": {
+        "operationId": "listUsers",
+        "responses": {"200": {"content": {"application/json": {"schema": {"$ref": "#/components/schemas/v0.User"}}}}}
+      }
+    }
+  },
+  "components": {"schemas": {"v0.User": {"type": "object", "properties": {
+    "name": {"type": "string"},
+    "nickname": {"type": ["string", "null"]},
+    "age": {"type": "integer", "nullable": true},
+    "tags": {"type": ["array", "null"], "items": {"type": ["string", "null"]}}
+  }}}}
+}`
+	g := testGenerator(t, spec)
+	files, err := g.ExecuteFiles()
+	if err != nil {
+		t.Fatal(err)
+	}
+	// gofmt aligns the fields, compare with single spaces
+	user := strings.Join(strings.Fields(string(files["smapiv0/user.go"])), " ")
+	for _, field := range []string{"Name string", "Nickname *string", "Age *int", "Tags []string"} {
+		if !strings.Contains(user, field) {
+			t.Errorf("expected field %q in\n%s", field, user)
+		}
+	}
+}
+
+func TestGeneratedCodeBuilds(t *testing.T) {
+	goTool, err := exec.LookPath("go")
+	if err != nil || testing.Short() {
+		t.Skip("go build of the generated code skipped")
+	}
+	spec := `{
+  "swagger": "2.0",
+  "paths": {
+    "/v0/users": {
+      "get": {"tags": ["users"], "operationId": "listUsers", "responses": {"200": {"schema": {"type": "array", "items": {"$ref": "#/definitions/v0.User"}}}}},
+      "post": {
+        "tags": ["users"],
+        "operationId": "listUsersCalls",
+        "parameters": [{"name": "user", "in": "body", "schema": {"$ref": "#/definitions/v0.User"}}],
+        "responses": {"201": {"schema": {"$ref": "#/definitions/v0.User"}}}
+      }
+    },
+    "/v0/users/{id}": {
+      "get": {
+        "tags": ["users"],
+        "operationId": "listUsersReturns",
+        "parameters": [{"name": "id", "in": "path", "type": "string"}, {"name": "fields", "in": "query", "type": "string"}],
+        "responses": {"200": {"schema": {"$ref": "#/definitions/v0.User"}}, "404": {"schema": {"$ref": "#/definitions/v0.Error"}}}
+      }
+    },
+    "/v0/fakes": {"get": {"tags": ["fake"], "operationId": "listFakes", "responses": {"204": {"description": "ok"}}}},
+    "/v0/news": {"get": {"tags": ["new"], "operationId": "fakeUsers", "responses": {"204": {"description": "ok"}}}},
+    "/v0/status": {"get": {"operationId": "fakeUsers", "responses": {"204": {"description": "ok"}}}}
+  },
+  "definitions": {
+    "v0.User": {"type": "object", "properties": {"name": {"type": "string"}}},
+    "v0.Error": {"type": "object", "properties": {"message": {"type": "string"}}}
+  }
+}`
+	g := testGenerator(t, spec)
+	g.Options.Fakes = true
+	g.Options.GroupByTag = true
+	g.Options.Context = true
+	if err = g.Execute(); err != nil {
+		t.Fatal(err)
+	}
+
+	// the output is the root of a module using this checkout of the runtime
+	repo, err := os.Getwd()
+	if err != nil {
+		t.Fatal(err)
+	}
+	goMod := "module example.com/gen\n\ngo 1.19\n\nrequire github.com/mlctrez/swaggerlt v0.0.0\n\n" +
+		"replace github.com/mlctrez/swaggerlt => " + strconv.Quote(repo) + "\n"
+	if err = os.WriteFile(filepath.Join(g.Options.OutputDir, "go.mod"), []byte(goMod), 0644); err != nil {
+		t.Fatal(err)
+	}
+	goSum, err := os.ReadFile("go.sum")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err = os.WriteFile(filepath.Join(g.Options.OutputDir, "go.sum"), goSum, 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	cmd := exec.Command(goTool, "build", "./...")
+	cmd.Dir = g.Options.OutputDir
+	cmd.Env = append(os.Environ(), "GOFLAGS=-mod=mod")
+	if output, err := cmd.CombinedOutput(); err != nil {
+		t.Fatalf("go build : %v\n%s", err, output)
+	}
+}
@@ -5,4 +5,5 @@ go 1.19
 require (
 	github.com/buger/jsonparser v1.1.1
 	github.com/dave/jennifer v1.6.0
+	gopkg.in/yaml.v3 v3.0.1
 )
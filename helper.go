@@ -2,6 +2,7 @@ package swaggerlt
 
 import (
 	"bytes"
+	"context"
 	"encoding/json"
 	"fmt"
 	"io"
@@ -10,6 +11,7 @@ import (
 	"reflect"
 	"strconv"
 	"strings"
+	"time"
 )
 
 func NewRequestHelper(method, endpoint, uri string) *RequestHelper {
@@ -26,7 +28,9 @@ func NewRequestHelper(method, endpoint, uri string) *RequestHelper {
 }
 
 type RequestHelper struct {
-	Endpoint      string
+	Endpoint string
+	// BasePath is inserted between Endpoint and Uri when not empty.
+	BasePath      string
 	Uri           string
 	Method        string
 	QueryValues   url.Values
@@ -35,6 +39,18 @@ type RequestHelper struct {
 	ResponseTypes map[int]any
 	Body          any
 	Response      any
+	// Middleware wraps the sending of the request, the first one is the outermost.
+	Middleware []Middleware
+	// Security holds the alternative security requirements of the operation, Credentials the
+	// credentials by security scheme name.
+	Security    []SecurityRequirement
+	Credentials map[string]Credential
+	// Retry retries failed attempts, nil sends the request once.
+	Retry *RetryPolicy
+	// Operation is the generated method name of the operation.
+	Operation string
+	// Limiter delays every attempt until it may be sent, when not nil.
+	Limiter Limiter
 }
 
 func (u *RequestHelper) Param(name string, value any) {
@@ -76,50 +92,31 @@ func (u *RequestHelper) ResponseType(code int, body any) {
 }
 
 func (u *RequestHelper) Execute(client *http.Client) (err error) {
+	return u.ExecuteContext(context.Background(), client)
+}
 
-	// calculate url from parameters
-	uri := u.Uri
-	for name, param := range u.PathParam {
-		uri = strings.ReplaceAll(uri, fmt.Sprintf("{%s}", name), param)
-	}
-	if len(u.QueryValues) > 0 {
-		uri += "?" + u.QueryValues.Encode()
-	}
-
-	var body io.Reader
+// ExecuteContext is Execute with a context which cancels the request or sets its deadline.
+func (u *RequestHelper) ExecuteContext(ctx context.Context, client *http.Client) (err error) {
 
+	var body []byte
 	if u.Body != nil {
-		var marshal []byte
-		if marshal, err = json.Marshal(u.Body); err != nil {
+		if body, err = json.Marshal(u.Body); err != nil {
 			return
 		}
-		body = bytes.NewReader(marshal)
-	} else {
-		body = bytes.NewBufferString("")
-	}
-
-	var request *http.Request
-	if request, err = http.NewRequest(u.Method, u.Endpoint+uri, body); err != nil {
-		return
 	}
 
-	if u.Body != nil {
-		request.Header.Set("Content-Type", "application/json")
-	}
-	for header, value := range u.Headers {
-		request.Header.Set(header, value)
+	var doer Doer = http.DefaultClient
+	if client != nil {
+		doer = client
 	}
 
 	var response *http.Response
-	if response, err = client.Do(request); err != nil {
+	if response, err = u.send(ctx, Chain(doer, u.Middleware...), body); err != nil {
 		return err
 	}
 
 	// dispose of the body and close
-	defer func() {
-		_, _ = io.ReadAll(response.Body)
-		_ = request.Body.Close()
-	}()
+	defer discard(response)
 
 	if response.StatusCode > 299 {
 		if rt := u.ResponseTypes[response.StatusCode]; rt == nil {
@@ -133,14 +130,97 @@ func (u *RequestHelper) Execute(client *http.Client) (err error) {
 	} else {
 		if u.Response != nil {
 			err = json.NewDecoder(response.Body).Decode(u.Response)
-		} else {
-			_, _ = io.ReadAll(request.Body)
 		}
 	}
 
 	return
 }
 
+// send sends the request with doer. When it is answered with 401 and one of the applied credentials
+// can obtain a new token the request is sent once more. Failed attempts are retried following Retry,
+// each attempt sends a new request with the same body.
+func (u *RequestHelper) send(ctx context.Context, doer Doer, body []byte) (response *http.Response, err error) {
+	refreshed := false
+	for attempt := 1; ; {
+		var request *http.Request
+		var credentials []Credential
+		if request, credentials, err = u.newRequest(ctx, body); err != nil {
+			return
+		}
+		if u.Limiter != nil {
+			if err = u.Limiter.Wait(ctx, u.Operation); err != nil {
+				return
+			}
+		}
+		response, err = doer.Do(request)
+
+		if err == nil && response.StatusCode == http.StatusUnauthorized && !refreshed && invalidate(credentials) {
+			refreshed = true
+			discard(response)
+			continue
+		}
+
+		wait, retry := u.Retry.backoff(request, response, err, attempt)
+		if !retry {
+			return
+		}
+		if err == nil {
+			discard(response)
+		}
+		timer := time.NewTimer(wait)
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+			return nil, ctx.Err()
+		case <-timer.C:
+		}
+		attempt++
+	}
+}
+
+func discard(response *http.Response) {
+	_, _ = io.Copy(io.Discard, response.Body)
+	_ = response.Body.Close()
+}
+
+// newRequest builds the http request and applies the credentials, which are returned.
+func (u *RequestHelper) newRequest(ctx context.Context, body []byte) (request *http.Request, credentials []Credential, err error) {
+
+	// calculate url from parameters
+	uri := u.Uri
+	for name, param := range u.PathParam {
+		uri = strings.ReplaceAll(uri, fmt.Sprintf("{%s}", name), param)
+	}
+	if len(u.QueryValues) > 0 {
+		uri += "?" + u.QueryValues.Encode()
+	}
+
+	if u.Operation != "" {
+		ctx = withOperation(ctx, u.Operation)
+	}
+	if request, err = http.NewRequestWithContext(ctx, u.Method, u.url(uri), bytes.NewReader(body)); err != nil {
+		return
+	}
+
+	if u.Body != nil {
+		request.Header.Set("Content-Type", "application/json")
+	}
+	for header, value := range u.Headers {
+		request.Header.Set(header, value)
+	}
+	credentials, err = u.authenticate(request)
+	return
+}
+
+// url joins Endpoint, BasePath and uri without doubling or dropping slashes between them.
+func (u *RequestHelper) url(uri string) string {
+	basePath := strings.Trim(u.BasePath, "/")
+	if basePath == "" {
+		return u.Endpoint + uri
+	}
+	return strings.TrimSuffix(u.Endpoint, "/") + "/" + basePath + "/" + strings.TrimPrefix(uri, "/")
+}
+
 type Error struct {
 	StatusCode int
 	Body       any
@@ -0,0 +1,91 @@
+package swaggerlt
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+	"unicode"
+)
+
+// Layout decides which client package an operation is generated in.
+type Layout interface {
+	// Package returns the name appended to the service name to form the package of op,
+	// an empty string selects the service package itself.
+	Package(op *Operation) string
+}
+
+// VersionLayout groups operations by a leading version segment, /v0/skills goes to smapiv0.
+// Paths without a version segment go to the service package.
+type VersionLayout struct{}
+
+func (VersionLayout) Package(op *Operation) string {
+	segment := strings.Split(strings.TrimPrefix(op.Path, "/"), "/")[0]
+	if versionSegment.MatchString(segment) {
+		return segment
+	}
+	return ""
+}
+
+// TagLayout groups operations by their first tag.
+type TagLayout struct{}
+
+func (TagLayout) Package(op *Operation) string {
+	if len(op.Tags) == 0 {
+		return ""
+	}
+	return packageName(op.Tags[0])
+}
+
+// RegexLayout groups operations by the first capture group of Regex matched against the path.
+// Paths that do not match go to the service package.
+type RegexLayout struct {
+	Regex *regexp.Regexp
+}
+
+func (r *RegexLayout) Package(op *Operation) string {
+	match := r.Regex.FindStringSubmatch(op.Path)
+	if len(match) < 2 {
+		return ""
+	}
+	return packageName(match[1])
+}
+
+// FlatLayout generates all operations in the service package.
+type FlatLayout struct{}
+
+func (FlatLayout) Package(_ *Operation) string {
+	return ""
+}
+
+// ParseLayout returns the layout for version, tag, flat or regex:<expression>.
+func ParseLayout(name string) (Layout, error) {
+	switch name {
+	case "", "version":
+		return VersionLayout{}, nil
+	case "tag":
+		return TagLayout{}, nil
+	case "flat":
+		return FlatLayout{}, nil
+	}
+	if strings.HasPrefix(name, "regex:") {
+		regex, err := regexp.Compile(strings.TrimPrefix(name, "regex:"))
+		if err != nil {
+			return nil, fmt.Errorf("layout %s : %w", name, err)
+		}
+		if regex.NumSubexp() == 0 {
+			return nil, fmt.Errorf("layout %s : no capture group", name)
+		}
+		return &RegexLayout{Regex: regex}, nil
+	}
+	return nil, fmt.Errorf("unknown layout %q, use version, tag, flat or regex:<expression>", name)
+}
+
+// packageName lower cases name and drops the characters not allowed in package names.
+func packageName(name string) string {
+	return strings.Map(func(r rune) rune {
+		if unicode.IsLetter(r) || unicode.IsDigit(r) {
+			return unicode.ToLower(r)
+		}
+		return -1
+	}, name)
+}
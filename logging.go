@@ -0,0 +1,188 @@
+//go:build go1.21
+
+package swaggerlt
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"log/slog"
+	"net/http"
+	"net/url"
+	"sort"
+	"strings"
+	"time"
+)
+
+const redacted = "REDACTED"
+
+// LogOptions configures LoggingMiddleware.
+type LogOptions struct {
+	// Level of the records of sent requests, transport errors are logged at slog.LevelError.
+	Level slog.Level
+	// Headers logs the request and response headers.
+	Headers bool
+	// Bodies logs the request and response bodies, truncated to MaxBodySize bytes.
+	Bodies bool
+	// MaxBodySize is the number of body bytes read and logged, 4096 when zero. Larger bodies are not logged
+	// when RedactFields is set, as the fields of a truncated body can not be redacted.
+	MaxBodySize int
+	// RedactHeaders are logged as REDACTED in addition to Authorization, Proxy-Authorization, Cookie and Set-Cookie.
+	RedactHeaders []string
+	// RedactQuery are the query parameters logged as REDACTED.
+	RedactQuery []string
+	// RedactFields are the json fields, at any depth of a body, logged as REDACTED.
+	RedactFields []string
+}
+
+var defaultRedactHeaders = []string{"Authorization", "Proxy-Authorization", "Cookie", "Set-Cookie"}
+
+// LoggingMiddleware logs every attempt to send a request with the operation name, method, url, status
+// and duration, and optionally the headers and bodies, redacting the values listed in options.
+func LoggingMiddleware(logger *slog.Logger, options *LogOptions) Middleware {
+	if options == nil {
+		options = &LogOptions{}
+	}
+	return func(next Doer) Doer {
+		return DoerFunc(func(request *http.Request) (*http.Response, error) {
+			attrs := []slog.Attr{
+				slog.String("operation", OperationName(request)),
+				slog.String("method", request.Method),
+				slog.String("url", options.redactURL(request.URL)),
+			}
+			if options.Headers {
+				attrs = append(attrs, options.headers("request_headers", request.Header))
+			}
+			if options.Bodies && request.GetBody != nil {
+				if body, err := request.GetBody(); err == nil {
+					if data, _ := io.ReadAll(io.LimitReader(body, int64(options.maxBodySize())+1)); len(data) > 0 {
+						attrs = append(attrs, slog.String("request_body", options.redactBody(data)))
+					}
+				}
+			}
+
+			start := time.Now()
+			response, err := next.Do(request)
+			attrs = append(attrs, slog.Duration("duration", time.Since(start)))
+			ctx := request.Context()
+			if err != nil {
+				attrs = append(attrs, slog.String("error", err.Error()))
+				logger.LogAttrs(ctx, slog.LevelError, "request failed", attrs...)
+				return response, err
+			}
+
+			attrs = append(attrs, slog.Int("status", response.StatusCode))
+			if options.Headers {
+				attrs = append(attrs, options.headers("response_headers", response.Header))
+			}
+			if options.Bodies {
+				// only the logged bytes are read, the rest of the body is streamed to the caller
+				var data []byte
+				if data, err = io.ReadAll(io.LimitReader(response.Body, int64(options.maxBodySize())+1)); err != nil {
+					return response, err
+				}
+				response.Body = readCloser{Reader: io.MultiReader(bytes.NewReader(data), response.Body), Closer: response.Body}
+				attrs = append(attrs, slog.String("response_body", options.redactBody(data)))
+			}
+			logger.LogAttrs(ctx, options.Level, "request", attrs...)
+			return response, nil
+		})
+	}
+}
+
+// readCloser replays a response body that was read for logging.
+type readCloser struct {
+	io.Reader
+	io.Closer
+}
+
+func (o *LogOptions) headers(key string, header http.Header) slog.Attr {
+	redact := append(append([]string(nil), defaultRedactHeaders...), o.RedactHeaders...)
+	var names []string
+	for name := range header {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	var attrs []any
+	for _, name := range names {
+		value := strings.Join(header[name], ", ")
+		if containsFold(redact, name) {
+			value = redacted
+		}
+		attrs = append(attrs, slog.String(name, value))
+	}
+	return slog.Group(key, attrs...)
+}
+
+func (o *LogOptions) redactURL(u *url.URL) string {
+	if len(o.RedactQuery) == 0 || u.RawQuery == "" {
+		return u.String()
+	}
+	query := u.Query()
+	for name := range query {
+		if containsFold(o.RedactQuery, name) {
+			query[name] = []string{redacted}
+		}
+	}
+	copied := *u
+	copied.RawQuery = query.Encode()
+	return copied.String()
+}
+
+// redactBody redacts the RedactFields of a json body and truncates it to MaxBodySize.
+// data holds at most MaxBodySize bytes of the body and one more when it is larger.
+func (o *LogOptions) redactBody(data []byte) string {
+	maxBodySize := o.maxBodySize()
+	if len(data) > maxBodySize && len(o.RedactFields) > 0 {
+		return fmt.Sprintf("more than %d bytes, not logged as RedactFields can not be applied", maxBodySize)
+	}
+	if len(o.RedactFields) > 0 {
+		var value any
+		if json.Unmarshal(data, &value) == nil {
+			if redactedData, err := json.Marshal(o.redactValue(value)); err == nil {
+				data = redactedData
+			}
+		}
+	}
+	if len(data) > maxBodySize {
+		return string(data[:maxBodySize]) + "..."
+	}
+	return string(data)
+}
+
+func (o *LogOptions) maxBodySize() int {
+	if o.MaxBodySize <= 0 {
+		return 4096
+	}
+	return o.MaxBodySize
+}
+
+func (o *LogOptions) redactValue(value any) any {
+	switch v := value.(type) {
+	case map[string]any:
+		for key, field := range v {
+			if containsFold(o.RedactFields, key) {
+				v[key] = redacted
+			} else {
+				v[key] = o.redactValue(field)
+			}
+		}
+	case []any:
+		for i, item := range v {
+			v[i] = o.redactValue(item)
+		}
+	}
+	return value
+}
+
+// containsFold compares case-insensitively as header names and field names vary in case.
+func containsFold(names []string, name string) bool {
+	for _, n := range names {
+		if strings.EqualFold(n, name) {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,121 @@
+//go:build go1.21
+
+package swaggerlt
+
+import (
+	"bytes"
+	"encoding/json"
+	"io"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+)
+
+func TestRedactValue(t *testing.T) {
+	options := &LogOptions{RedactFields: []string{"password", "Token"}}
+	for _, test := range []struct {
+		name     string
+		value    string
+		expected string
+	}{
+		{"top level", `{"user": "a", "password": "b"}`, `{"password": "REDACTED", "user": "a"}`},
+		{"case insensitive", `{"token": "b", "TOKEN": "c"}`, `{"TOKEN": "REDACTED", "token": "REDACTED"}`},
+		{"nested", `{"auth": {"password": {"value": "b"}}}`, `{"auth": {"password": "REDACTED"}}`},
+		{"in arrays", `[{"password": "b"}, {"name": "c"}]`, `[{"password": "REDACTED"}, {"name": "c"}]`},
+		{"scalar", `"password"`, `"password"`},
+		{"no match", `{"name": "a", "list": [1, 2]}`, `{"list": [1, 2], "name": "a"}`},
+	} {
+		t.Run(test.name, func(t *testing.T) {
+			var value, expected any
+			if err := json.Unmarshal([]byte(test.value), &value); err != nil {
+				t.Fatal(err)
+			}
+			if err := json.Unmarshal([]byte(test.expected), &expected); err != nil {
+				t.Fatal(err)
+			}
+			actual, _ := json.Marshal(options.redactValue(value))
+			expectedData, _ := json.Marshal(expected)
+			if !bytes.Equal(actual, expectedData) {
+				t.Errorf("expected %s, got %s", expectedData, actual)
+			}
+		})
+	}
+}
+
+func TestRedactBody(t *testing.T) {
+	options := &LogOptions{RedactFields: []string{"secret"}, MaxBodySize: 21}
+	if body := options.redactBody([]byte(`{"secret": "value"}`)); body != `{"secret":"REDACTED"}` {
+		t.Errorf("unexpected body %s", body)
+	}
+	if body := options.redactBody([]byte(`{"secret": "a longer value"}`)); strings.Contains(body, "value") {
+		t.Errorf("a truncated body was logged without redaction : %s", body)
+	}
+	options = &LogOptions{MaxBodySize: 21}
+	if body := options.redactBody([]byte(`not json, but long enough to truncate`)); body != `not json, but long en...` {
+		t.Errorf("unexpected body %s", body)
+	}
+}
+
+func TestRedactHeaders(t *testing.T) {
+	options := &LogOptions{RedactHeaders: []string{"X-Api-Key"}}
+	header := http.Header{}
+	for _, name := range []string{"Authorization", "Cookie", "X-Api-Key", "Accept"} {
+		header.Set(name, "secret")
+	}
+	attr := options.headers("headers", header)
+	for _, a := range attr.Value.Group() {
+		if redacted := a.Value.String() == "REDACTED"; redacted != (a.Key != "Accept") {
+			t.Errorf("%s : unexpected value %s", a.Key, a.Value)
+		}
+	}
+}
+
+func TestRedactURL(t *testing.T) {
+	options := &LogOptions{RedactQuery: []string{"api_key"}}
+	u, _ := url.Parse("https://example.com/users?api_key=secret&page=2")
+	if redactedURL := options.redactURL(u); redactedURL != "https://example.com/users?api_key=REDACTED&page=2" {
+		t.Errorf("unexpected url %s", redactedURL)
+	}
+	if u.RawQuery != "api_key=secret&page=2" {
+		t.Errorf("the request url was changed to %s", u)
+	}
+}
+
+func TestLoggingMiddleware(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Set-Cookie", "session=secret")
+		_, _ = io.WriteString(w, `{"name": "user", "token": "secret", "padding": "`+strings.Repeat("x", 100)+`"}`)
+	}))
+	defer server.Close()
+
+	out := &bytes.Buffer{}
+	logger := slog.New(slog.NewTextHandler(out, nil))
+	options := &LogOptions{Headers: true, Bodies: true, RedactFields: []string{"token"}, RedactHeaders: []string{"X-Api-Key"}, MaxBodySize: 40}
+
+	h := NewRequestHelper("post", server.URL, "/users")
+	h.Operation = "CreateUser"
+	h.Headers["Authorization"] = "Bearer secret"
+	h.Body = map[string]string{"token": "secret"}
+	h.Middleware = []Middleware{LoggingMiddleware(logger, options)}
+	response := map[string]string{}
+	h.Response = &response
+
+	if err := h.Execute(server.Client()); err != nil {
+		t.Fatal(err)
+	}
+	if response["token"] != "secret" || len(response["padding"]) != 100 {
+		t.Errorf("the response body was not replayed, got %v", response)
+	}
+	logged := out.String()
+	if strings.Contains(logged, "secret") {
+		t.Errorf("secret was logged : %s", logged)
+	}
+	for _, expected := range []string{"operation=CreateUser", "method=POST", "status=200", "request_headers.Authorization=REDACTED"} {
+		if !strings.Contains(logged, expected) {
+			t.Errorf("%s was not logged : %s", expected, logged)
+		}
+	}
+}
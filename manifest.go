@@ -0,0 +1,116 @@
+package swaggerlt
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"sync"
+)
+
+// Manifest lists the files produced by a generator run with the sha256 of their content.
+// It is written next to the generated packages and used by the next run to delete files
+// that are no longer generated.
+type Manifest struct {
+	Files map[string]string `json:"files"`
+}
+
+func newManifest() *Manifest {
+	return &Manifest{Files: map[string]string{}}
+}
+
+func hashContent(data []byte) string {
+	sum := sha256.Sum256(data)
+	return hex.EncodeToString(sum[:])
+}
+
+// manifestName is the name of the manifest of the service, so several services can share an output directory.
+func (g *Generator) manifestName() string {
+	return ".swaggerlt-" + g.Options.ServiceName + ".json"
+}
+
+// readManifest returns the manifest of the previous run or an empty manifest when there is none.
+func (g *Generator) readManifest(sink PruningSink) (*Manifest, error) {
+	data, err := sink.ReadFile(g.manifestName())
+	if errors.Is(err, fs.ErrNotExist) {
+		return newManifest(), nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	manifest := newManifest()
+	if err = json.Unmarshal(data, manifest); err != nil {
+		return nil, fmt.Errorf("%s : %w", g.manifestName(), err)
+	}
+	return manifest, nil
+}
+
+// manifestSink records the hash of every file written through it.
+type manifestSink struct {
+	FileSink
+	mutex    sync.Mutex
+	manifest *Manifest
+}
+
+func newManifestSink(sink FileSink) *manifestSink {
+	return &manifestSink{FileSink: sink, manifest: newManifest()}
+}
+
+func (m *manifestSink) WriteFile(name string, data []byte) error {
+	m.mutex.Lock()
+	m.manifest.Files[name] = hashContent(data)
+	m.mutex.Unlock()
+	return m.FileSink.WriteFile(name, data)
+}
+
+// writeManifest writes the manifest of this run and, when the sink supports it, removes the files of
+// the previous manifest that were not generated again. Files changed since they were generated are kept.
+// When the run had errors nothing is removed and the previous entries stay in the manifest.
+func (g *Generator) writeManifest(sink FileSink, manifest *Manifest) (err error) {
+	if pruning, ok := sink.(PruningSink); ok {
+		var previous *Manifest
+		if previous, err = g.readManifest(pruning); err != nil {
+			return
+		}
+		for name, hash := range previous.Files {
+			if _, ok := manifest.Files[name]; ok {
+				continue
+			}
+			if g.report.HasErrors() {
+				manifest.Files[name] = hash
+				continue
+			}
+			if err = g.prune(pruning, name, hash); err != nil {
+				return
+			}
+		}
+	}
+
+	var data []byte
+	if data, err = json.MarshalIndent(manifest, "", "  "); err != nil {
+		return
+	}
+	return sink.WriteFile(g.manifestName(), append(data, '\n'))
+}
+
+func (g *Generator) prune(sink PruningSink, name, hash string) error {
+	data, err := sink.ReadFile(name)
+	if errors.Is(err, fs.ErrNotExist) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	if hashContent(data) != hash {
+		g.report.warnf("", "%s is no longer generated but was modified, not removed", name)
+		return nil
+	}
+	g.logf("removing %s", name)
+	if err = sink.Remove(name); err != nil && !os.IsNotExist(err) {
+		return err
+	}
+	return nil
+}
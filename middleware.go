@@ -0,0 +1,45 @@
+package swaggerlt
+
+import (
+	"context"
+	"net/http"
+)
+
+// Doer sends a request, *http.Client implements it.
+type Doer interface {
+	Do(request *http.Request) (*http.Response, error)
+}
+
+// DoerFunc adapts a function to a Doer.
+type DoerFunc func(request *http.Request) (*http.Response, error)
+
+func (f DoerFunc) Do(request *http.Request) (*http.Response, error) {
+	return f(request)
+}
+
+// Middleware wraps the sending of a request, it can change the request, the response or both, for logging,
+// metrics, tracing headers or request signing. It is called for every attempt with the request built by
+// RequestHelper, after credentials were applied, and OperationName returns the operation of the request.
+type Middleware func(next Doer) Doer
+
+// Chain wraps doer with middleware, the first middleware is the outermost.
+func Chain(doer Doer, middleware ...Middleware) Doer {
+	for i := len(middleware) - 1; i >= 0; i-- {
+		doer = middleware[i](doer)
+	}
+	return doer
+}
+
+type operationKey struct{}
+
+// withOperation returns ctx carrying the generated method name of the operation.
+func withOperation(ctx context.Context, operation string) context.Context {
+	return context.WithValue(ctx, operationKey{}, operation)
+}
+
+// OperationName returns the generated method name of the operation a request was built for,
+// an empty string for requests not built by RequestHelper.
+func OperationName(request *http.Request) string {
+	operation, _ := request.Context().Value(operationKey{}).(string)
+	return operation
+}
@@ -1,25 +1,28 @@
 package swaggerlt
 
 import (
+	"regexp"
 	"strings"
+	"unicode"
 )
 
-func toGoNameUpper(in string) string {
-	in = toGoName(in)
-
-	return strings.ToUpper(in[0:1]) + in[1:]
+func toGoNameUpper(in string) (string, error) {
+	in, err := toGoName(in)
+	if err != nil {
+		return "", err
+	}
+	return strings.ToUpper(in[0:1]) + in[1:], nil
 }
 
-func toGoNameLower(in string) string {
-	in = toGoName(in)
-	return strings.ToLower(in[0:1]) + in[1:]
+func toGoNameLower(in string) (string, error) {
+	in, err := toGoName(in)
+	if err != nil {
+		return "", err
+	}
+	return strings.ToLower(in[0:1]) + in[1:], nil
 }
 
-func toGoName(in string) string {
-
-	if in == "" {
-		panic("toGoName with empty string")
-	}
+func toGoName(in string) (string, error) {
 
 	// remove leading _ as in _links
 	for strings.HasPrefix(in, "_") {
@@ -31,12 +34,101 @@ func toGoName(in string) string {
 	in = strings.ReplaceAll(in, "-", "_")
 	in = strings.ReplaceAll(in, ":", "_")
 
+	if in == "" {
+		return "", errEmptyName
+	}
 	if in == "type" {
-		return "type_"
+		return "type_", nil
 	}
 	if in[0] >= '0' && in[0] <= '9' {
-		return "n" + in
+		return "n" + in, nil
+	}
+
+	return in, nil
+}
+
+var versionSegment = regexp.MustCompile(`^v\d+$`)
+
+// synthesizeName derives an operation name from the verb and path, like GetUsersByIdPets
+// for get /v1/users/{id}/pets. A leading version segment is left out as it names the package.
+func synthesizeName(verb, path string) string {
+	segments := strings.Split(strings.Trim(path, "/"), "/")
+	if len(segments) > 0 && versionSegment.MatchString(segments[0]) {
+		segments = segments[1:]
+	}
+
+	name := &strings.Builder{}
+	name.WriteString(upperFirst(verb))
+	for _, segment := range segments {
+		if strings.HasPrefix(segment, "{") && strings.HasSuffix(segment, "}") {
+			name.WriteString("By")
+		}
+		name.WriteString(camelCase(segment))
+	}
+	return name.String()
+}
+
+// camelCase joins the words of in, separated by anything but letters and digits, with upper cased first letters.
+func camelCase(in string) string {
+	words := strings.FieldsFunc(in, func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsDigit(r)
+	})
+	for i, word := range words {
+		words[i] = upperFirst(word)
 	}
+	return strings.Join(words, "")
+}
+
+// tagName returns the go name of the sub-client of a tag, an empty string when the tag has no usable characters.
+func tagName(tag string) string {
+	name := camelCase(tag)
+	if name != "" && name[0] >= '0' && name[0] <= '9' {
+		name = "N" + name
+	}
+	return name
+}
+
+// operationName returns the go name for an operationId, like UpdateUser for update-user.
+func operationName(operationID string) string {
+	name := camelCase(operationID)
+	if name != "" && name[0] >= '0' && name[0] <= '9' {
+		name = "N" + name
+	}
+	return name
+}
+
+// buildSuffixes are the GOOS and GOARCH values, and test, which go build reads from the last _ separated
+// element of a file name.
+var buildSuffixes = map[string]bool{"test": true,
+	"aix": true, "android": true, "darwin": true, "dragonfly": true, "freebsd": true, "hurd": true,
+	"illumos": true, "ios": true, "js": true, "linux": true, "nacl": true, "netbsd": true, "openbsd": true,
+	"plan9": true, "solaris": true, "wasip1": true, "windows": true, "zos": true,
+	"386": true, "amd64": true, "amd64p32": true, "arm": true, "armbe": true, "arm64": true, "arm64be": true,
+	"loong64": true, "mips": true, "mipsle": true, "mips64": true, "mips64le": true, "mips64p32": true,
+	"mips64p32le": true, "ppc": true, "ppc64": true, "ppc64le": true, "riscv": true, "riscv64": true,
+	"s390": true, "s390x": true, "sparc": true, "sparc64": true, "wasm": true,
+}
+
+// goFileName returns the name of the generated file for the go name of an operation or type.
+// Names ending in _test or _<GOOS> or _<GOARCH> get a _gen suffix so go build does not skip the file.
+func goFileName(name string) string {
+	name = lowerFirst(name)
+	if i := strings.LastIndex(name, "_"); i >= 0 && buildSuffixes[name[i+1:]] {
+		name += "_gen"
+	}
+	return name + ".go"
+}
+
+func upperFirst(in string) string {
+	if in == "" {
+		return in
+	}
+	return strings.ToUpper(in[0:1]) + in[1:]
+}
 
-	return in
+func lowerFirst(in string) string {
+	if in == "" {
+		return in
+	}
+	return strings.ToLower(in[0:1]) + in[1:]
 }
@@ -0,0 +1,98 @@
+package swaggerlt
+
+import (
+	"regexp"
+	"testing"
+)
+
+func TestOperationName(t *testing.T) {
+	for operationID, expected := range map[string]string{
+		"update-user":   "UpdateUser",
+		"run-test":      "RunTest",
+		"list users":    "ListUsers",
+		"users/get":     "UsersGet",
+		"getUser":       "GetUser",
+		"2fa.setup":     "N2faSetup",
+		"-/-":           "",
+		"get_user_pets": "GetUserPets",
+	} {
+		if name := operationName(operationID); name != expected {
+			t.Errorf("%q : expected %q, got %q", operationID, expected, name)
+		}
+	}
+}
+
+func TestGoFileName(t *testing.T) {
+	for name, expected := range map[string]string{
+		"GetUser":       "getUser.go",
+		"Run_test":      "run_test_gen.go",
+		"Status_linux":  "status_linux_gen.go",
+		"Build_windows": "build_windows_gen.go",
+		"Arch_arm64":    "arch_arm64_gen.go",
+		"Latest":        "latest.go",
+		"Get_tests":     "get_tests.go",
+	} {
+		if fileName := goFileName(name); fileName != expected {
+			t.Errorf("%s : expected %s, got %s", name, expected, fileName)
+		}
+	}
+}
+
+func TestNameOperations(t *testing.T) {
+	g := &Generator{Options: &Options{ServiceName: "smapi", PathRegex: regexp.MustCompile(".*")}, report: &Report{}}
+	ops := []*Operation{
+		{Path: "/v0/tests", Verb: "post", OperationID: "run-test"},
+		{Path: "/v0/users", Verb: "put", OperationID: "update user"},
+		{Path: "/v0/users/{id}", Verb: "get", XOperationName: "get_user"},
+		{Path: "/v0/users/{id}", Verb: "delete", XOperationName: "delete user"},
+		{Path: "/v0/pets", Verb: "get", OperationID: "..."},
+	}
+	g.nameOperations(ops)
+	for i, expected := range []string{"RunTest", "UpdateUser", "Get_user", "DeleteUser", "GetPets"} {
+		if ops[i].GoName != expected {
+			t.Errorf("%s %s : expected %s, got %s", ops[i].Verb, ops[i].Path, expected, ops[i].GoName)
+		}
+	}
+	if g.report.Count(SeverityWarning) != 1 {
+		t.Errorf("expected a warning for the unusable operationId, got %d", g.report.Count(SeverityWarning))
+	}
+}
+
+func TestOperationTag(t *testing.T) {
+	for _, test := range []struct {
+		tag      string
+		fakes    bool
+		expected string
+	}{
+		{"users", false, "Users"},
+		{"headers", false, "HeadersTag"},
+		{"new", false, "NewTag"},
+		{"fake", false, "Fake"},
+		{"fake", true, "FakeTag"},
+	} {
+		t.Run(test.tag, func(t *testing.T) {
+			g := &Generator{Options: &Options{GroupByTag: true, Fakes: test.fakes}}
+			if name := g.operationTag(&Operation{Tags: []string{test.tag}}); name != test.expected {
+				t.Errorf("expected %s, got %s", test.expected, name)
+			}
+		})
+	}
+}
+
+func TestNameOperationsWithFakes(t *testing.T) {
+	g := &Generator{Options: &Options{ServiceName: "smapi", PathRegex: regexp.MustCompile(".*"), Fakes: true, GroupByTag: true},
+		report: &Report{}}
+	ops := []*Operation{
+		{Path: "/v0/users", Verb: "get", OperationID: "listUsers"},
+		{Path: "/v0/users/calls", Verb: "get", OperationID: "listUsersCalls"},
+		{Path: "/v0/users/returns", Verb: "get", OperationID: "listUsersReturns"},
+		{Path: "/v0/users/fake", Verb: "get", OperationID: "fakeUsers"},
+		{Path: "/v0/users/{id}", Verb: "get", OperationID: "getUser", Tags: []string{"users"}},
+	}
+	g.nameOperations(ops)
+	for i, expected := range []string{"ListUsers", "GetUsersCalls", "GetUsersReturns", "GetUsersFake", "GetUser"} {
+		if ops[i].GoName != expected {
+			t.Errorf("%s %s : expected %s, got %s", ops[i].Verb, ops[i].Path, expected, ops[i].GoName)
+		}
+	}
+}
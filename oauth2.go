@@ -0,0 +1,140 @@
+package swaggerlt
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+	"sync"
+	"time"
+)
+
+// OAuth2 is a TokenSource fetching access tokens from TokenURL with the client credentials grant, or with
+// the refresh token grant when RefreshToken is set. Tokens are cached and fetched again shortly before
+// they expire or when a request using them was answered with 401.
+type OAuth2 struct {
+	TokenURL     string
+	ClientID     string
+	ClientSecret string
+	Scopes       []string
+	// RefreshToken selects the refresh token grant, it is replaced by refresh tokens returned by TokenURL.
+	RefreshToken string
+	// Client sends the token requests, http.DefaultClient when nil.
+	Client *http.Client
+	// EarlyExpiry fetches a new token this long before the current one expires, 30 seconds when zero.
+	EarlyExpiry time.Duration
+
+	mutex       sync.Mutex
+	accessToken string
+	expiry      time.Time
+}
+
+// oauth2Token is the successful response of a token endpoint.
+type oauth2Token struct {
+	AccessToken  string `json:"access_token"`
+	TokenType    string `json:"token_type"`
+	RefreshToken string `json:"refresh_token"`
+	ExpiresIn    int64  `json:"expires_in"`
+}
+
+// oauth2Error is the error response of a token endpoint.
+type oauth2Error struct {
+	Error            string `json:"error"`
+	ErrorDescription string `json:"error_description"`
+}
+
+// Token returns the cached access token or fetches a new one. Concurrent callers wait for a single fetch.
+func (o *OAuth2) Token(ctx context.Context) (string, error) {
+	o.mutex.Lock()
+	defer o.mutex.Unlock()
+
+	if o.accessToken != "" && (o.expiry.IsZero() || time.Now().Before(o.expiry.Add(-o.earlyExpiry()))) {
+		return o.accessToken, nil
+	}
+
+	token, err := o.fetch(ctx)
+	if err != nil {
+		return "", err
+	}
+	o.accessToken = token.AccessToken
+	o.expiry = time.Time{}
+	if token.ExpiresIn > 0 {
+		o.expiry = time.Now().Add(time.Duration(token.ExpiresIn) * time.Second)
+	}
+	if token.RefreshToken != "" && o.RefreshToken != "" {
+		o.RefreshToken = token.RefreshToken
+	}
+	return o.accessToken, nil
+}
+
+// Invalidate drops the cached token so the next Token call fetches a new one.
+func (o *OAuth2) Invalidate() bool {
+	o.mutex.Lock()
+	defer o.mutex.Unlock()
+	o.accessToken = ""
+	return true
+}
+
+func (o *OAuth2) earlyExpiry() time.Duration {
+	if o.EarlyExpiry == 0 {
+		return 30 * time.Second
+	}
+	return o.EarlyExpiry
+}
+
+func (o *OAuth2) fetch(ctx context.Context) (token *oauth2Token, err error) {
+	form := url.Values{}
+	if o.RefreshToken != "" {
+		form.Set("grant_type", "refresh_token")
+		form.Set("refresh_token", o.RefreshToken)
+	} else {
+		form.Set("grant_type", "client_credentials")
+	}
+	if len(o.Scopes) > 0 {
+		form.Set("scope", strings.Join(o.Scopes, " "))
+	}
+
+	var request *http.Request
+	if request, err = http.NewRequestWithContext(ctx, http.MethodPost, o.TokenURL, strings.NewReader(form.Encode())); err != nil {
+		return
+	}
+	request.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	request.Header.Set("Accept", "application/json")
+	if o.ClientID != "" {
+		request.SetBasicAuth(url.QueryEscape(o.ClientID), url.QueryEscape(o.ClientSecret))
+	}
+
+	client := o.Client
+	if client == nil {
+		client = http.DefaultClient
+	}
+	var response *http.Response
+	if response, err = client.Do(request); err != nil {
+		return nil, fmt.Errorf("oauth2 token : %w", err)
+	}
+	defer func() { _ = response.Body.Close() }()
+
+	var data []byte
+	if data, err = io.ReadAll(response.Body); err != nil {
+		return nil, fmt.Errorf("oauth2 token : %w", err)
+	}
+	if response.StatusCode > 299 {
+		tokenError := &oauth2Error{}
+		if json.Unmarshal(data, tokenError) == nil && tokenError.Error != "" {
+			return nil, fmt.Errorf("oauth2 token : status %d : %s %s", response.StatusCode, tokenError.Error, tokenError.ErrorDescription)
+		}
+		return nil, fmt.Errorf("oauth2 token : status %d", response.StatusCode)
+	}
+
+	token = &oauth2Token{}
+	if err = json.Unmarshal(data, token); err != nil {
+		return nil, fmt.Errorf("oauth2 token : %w", err)
+	}
+	if token.AccessToken == "" {
+		return nil, fmt.Errorf("oauth2 token : response has no access_token")
+	}
+	return
+}
@@ -0,0 +1,197 @@
+package swaggerlt
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+// tokenServer is a token endpoint issuing access tokens t1, t2 and so on, and refresh tokens r1, r2 and so on.
+type tokenServer struct {
+	*httptest.Server
+	ExpiresIn int
+
+	mutex    sync.Mutex
+	requests []map[string]string
+}
+
+func newTokenServer(t *testing.T) *tokenServer {
+	ts := &tokenServer{ExpiresIn: 3600}
+	ts.Server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if err := r.ParseForm(); err != nil {
+			t.Error(err)
+		}
+		clientID, clientSecret, _ := r.BasicAuth()
+		ts.mutex.Lock()
+		ts.requests = append(ts.requests, map[string]string{
+			"grant_type":    r.PostForm.Get("grant_type"),
+			"refresh_token": r.PostForm.Get("refresh_token"),
+			"scope":         r.PostForm.Get("scope"),
+			"client":        clientID + ":" + clientSecret,
+		})
+		n := len(ts.requests)
+		ts.mutex.Unlock()
+
+		if r.PostForm.Get("refresh_token") == "revoked" {
+			w.WriteHeader(http.StatusBadRequest)
+			_, _ = fmt.Fprint(w, `{"error": "invalid_grant", "error_description": "revoked"}`)
+			return
+		}
+		_ = json.NewEncoder(w).Encode(map[string]any{
+			"access_token":  fmt.Sprintf("t%d", n),
+			"refresh_token": fmt.Sprintf("r%d", n),
+			"token_type":    "Bearer",
+			"expires_in":    ts.ExpiresIn,
+		})
+	}))
+	t.Cleanup(ts.Close)
+	return ts
+}
+
+func (ts *tokenServer) fetches() []map[string]string {
+	ts.mutex.Lock()
+	defer ts.mutex.Unlock()
+	return append([]map[string]string(nil), ts.requests...)
+}
+
+func TestOAuth2Caching(t *testing.T) {
+	ts := newTokenServer(t)
+	source := &OAuth2{TokenURL: ts.URL, ClientID: "id", ClientSecret: "secret", Scopes: []string{"read", "write"}}
+
+	for i := 0; i < 3; i++ {
+		token, err := source.Token(context.Background())
+		if err != nil {
+			t.Fatal(err)
+		}
+		if token != "t1" {
+			t.Fatalf("expected the cached token t1, got %s", token)
+		}
+	}
+	fetches := ts.fetches()
+	if len(fetches) != 1 {
+		t.Fatalf("expected one token request, got %d", len(fetches))
+	}
+	expected := map[string]string{"grant_type": "client_credentials", "refresh_token": "", "scope": "read write", "client": "id:secret"}
+	for key, value := range expected {
+		if fetches[0][key] != value {
+			t.Errorf("expected %s %q, got %q", key, value, fetches[0][key])
+		}
+	}
+}
+
+func TestOAuth2EarlyExpiry(t *testing.T) {
+	for _, test := range []struct {
+		name        string
+		expiresIn   int
+		earlyExpiry time.Duration
+		fetches     int
+	}{
+		{"expires within the default early expiry", 10, 0, 2},
+		{"expires after the early expiry", 10, time.Second, 1},
+		{"no expiry", 0, 0, 1},
+	} {
+		t.Run(test.name, func(t *testing.T) {
+			ts := newTokenServer(t)
+			ts.ExpiresIn = test.expiresIn
+			source := &OAuth2{TokenURL: ts.URL, EarlyExpiry: test.earlyExpiry}
+			for i := 0; i < 2; i++ {
+				if _, err := source.Token(context.Background()); err != nil {
+					t.Fatal(err)
+				}
+			}
+			if fetches := len(ts.fetches()); fetches != test.fetches {
+				t.Errorf("expected %d token requests, got %d", test.fetches, fetches)
+			}
+		})
+	}
+}
+
+func TestOAuth2RefreshTokenRotation(t *testing.T) {
+	ts := newTokenServer(t)
+	source := &OAuth2{TokenURL: ts.URL, RefreshToken: "r0"}
+
+	for i := 1; i <= 3; i++ {
+		token, err := source.Token(context.Background())
+		if err != nil {
+			t.Fatal(err)
+		}
+		if expected := fmt.Sprintf("t%d", i); token != expected {
+			t.Fatalf("expected %s, got %s", expected, token)
+		}
+		source.Invalidate()
+	}
+	for i, fetch := range ts.fetches() {
+		if fetch["grant_type"] != "refresh_token" {
+			t.Errorf("expected the refresh_token grant, got %s", fetch["grant_type"])
+		}
+		// every request uses the refresh token returned by the previous one
+		if expected := fmt.Sprintf("r%d", i); fetch["refresh_token"] != expected {
+			t.Errorf("request %d : expected refresh token %s, got %s", i, expected, fetch["refresh_token"])
+		}
+	}
+}
+
+func TestOAuth2Error(t *testing.T) {
+	ts := newTokenServer(t)
+	source := &OAuth2{TokenURL: ts.URL, RefreshToken: "revoked"}
+	_, err := source.Token(context.Background())
+	if err == nil || !strings.Contains(err.Error(), "invalid_grant revoked") {
+		t.Errorf("expected the token endpoint error, got %v", err)
+	}
+}
+
+func TestOAuth2RetryOn401(t *testing.T) {
+	for _, test := range []struct {
+		name string
+		// accepted is the access token the api accepts
+		accepted string
+		status   int
+		requests int
+		fetches  int
+	}{
+		{"valid token", "t1", http.StatusOK, 1, 1},
+		{"revoked token", "t2", http.StatusOK, 2, 2},
+		{"rejected twice", "none", http.StatusUnauthorized, 2, 2},
+	} {
+		t.Run(test.name, func(t *testing.T) {
+			ts := newTokenServer(t)
+			requests := 0
+			api := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				requests++
+				if r.Header.Get("Authorization") != "Bearer "+test.accepted {
+					w.WriteHeader(http.StatusUnauthorized)
+					return
+				}
+				_, _ = fmt.Fprint(w, `{"name": "user"}`)
+			}))
+			defer api.Close()
+
+			h := NewRequestHelper("get", api.URL, "/users")
+			h.Security = []SecurityRequirement{{"oauth"}}
+			h.Credentials = map[string]Credential{"oauth": &BearerToken{Source: &OAuth2{TokenURL: ts.URL}}}
+			h.ResponseType(http.StatusUnauthorized, &map[string]any{})
+			response := map[string]string{}
+			h.Response = &response
+
+			err := h.Execute(api.Client())
+			if test.status == http.StatusOK && (err != nil || response["name"] != "user") {
+				t.Errorf("expected the user, got %v %v", response, err)
+			}
+			if test.status != http.StatusOK && err == nil {
+				t.Errorf("expected an error")
+			}
+			if requests != test.requests {
+				t.Errorf("expected %d api requests, got %d", test.requests, requests)
+			}
+			if fetches := len(ts.fetches()); fetches != test.fetches {
+				t.Errorf("expected %d token requests, got %d", test.fetches, fetches)
+			}
+		})
+	}
+}
@@ -0,0 +1,225 @@
+package swaggerlt
+
+import (
+	"fmt"
+	jp "github.com/buger/jsonparser"
+	"net/http"
+	"strconv"
+	"strings"
+)
+
+// isOpenAPI3 reports if the spec is an OpenAPI 3.x document rather than swagger 2.0.
+func isOpenAPI3(spec []byte) bool {
+	version, _ := jp.GetString(spec, "openapi")
+	return strings.HasPrefix(version, "3.")
+}
+
+// OperationFromOpenAPI3 reads an OpenAPI 3.x operation into the same model used for swagger 2.0.
+// The request body becomes a Parameter with In "body" and the json media type schemas of
+// requestBody and responses are used in place of the swagger 2.0 schema fields.
+func OperationFromOpenAPI3(spec []byte, path, verb string, value []byte) (op *Operation, err error) {
+	op = &Operation{Path: path, Verb: verb}
+
+	// tags and parameters are optional in OpenAPI 3
+	_, _ = jp.ArrayEach(value, op.tags, "tags")
+
+	op.Description, _ = jp.GetString(value, "description")
+	if op.Description == "" {
+		op.Description, _ = jp.GetString(value, "summary")
+	}
+
+	pointer := jsonPointer("paths", path, verb)
+	if op.Parameters, err = parametersFromOpenAPI3(spec, value, pointer); err != nil {
+		return
+	}
+
+	if err = op.requestBodyFromOpenAPI3(spec, value); err != nil {
+		err = specError(pointer+"/requestBody", err)
+		return
+	}
+
+	err = jp.ObjectEach(value, func(key []byte, value []byte, _ jp.ValueType, _ int) error {
+		return specError(pointer+"/responses/"+pointerKey(string(key)), op.responseFromOpenAPI3(spec, string(key), value))
+	}, "responses")
+	if err != nil {
+		return
+	}
+
+	op.XOperationName, _ = jp.GetString(value, "x-operation-name")
+	op.OperationID, _ = jp.GetString(value, "operationId")
+	op.Security = securityRequirements(value, "security")
+	op.RawData = value
+
+	return
+}
+
+// parametersFromOpenAPI3 reads the parameters of an operation or path item at pointer.
+func parametersFromOpenAPI3(spec, value []byte, pointer string) (params []*Parameter, err error) {
+	index := 0
+	_, _ = jp.ArrayEach(value, func(value []byte, _ jp.ValueType, _ int, _ error) {
+		if err != nil {
+			return
+		}
+		var p *Parameter
+		if p, err = parameterFromOpenAPI3(spec, value); err != nil {
+			err = specError(fmt.Sprintf("%s/parameters/%d", pointer, index), err)
+			return
+		}
+		params = append(params, p)
+		index++
+	}, "parameters")
+	return
+}
+
+func parameterFromOpenAPI3(spec, value []byte) (p *Parameter, err error) {
+	if value, err = resolveLocal(spec, value); err != nil {
+		return
+	}
+
+	p = &Parameter{}
+	p.NameOrig, _ = jp.GetString(value, "name")
+	if p.Name, err = toGoNameLower(p.NameOrig); err != nil {
+		return nil, fmt.Errorf("parameter name : %w", err)
+	}
+	p.In, _ = jp.GetString(value, "in")
+	p.Description, _ = jp.GetString(value, "description")
+	p.Required, _ = jp.GetBoolean(value, "required")
+
+	schema, _, _, _ := jp.Get(value, "schema")
+	if schema, err = resolveLocal(spec, schema); err != nil {
+		return
+	}
+	p.Type, _ = schemaType(schema)
+	p.Items, _ = schemaType(schema, "items")
+	p.ItemsRef, _ = jp.GetString(schema, "items", "$ref")
+
+	return
+}
+
+func (op *Operation) requestBodyFromOpenAPI3(spec, value []byte) (err error) {
+	body, _, _, getErr := jp.Get(value, "requestBody")
+	if getErr != nil {
+		return
+	}
+	if body, err = resolveLocal(spec, body); err != nil {
+		return
+	}
+
+	p := &Parameter{Name: "body", NameOrig: "body", In: "body"}
+	if name, _ := jp.GetString(value, "x-codegen-request-body-name"); name != "" {
+		p.NameOrig = name
+		if p.Name, err = toGoNameLower(name); err != nil {
+			return
+		}
+	}
+	p.Description, _ = jp.GetString(body, "description")
+	p.Required, _ = jp.GetBoolean(body, "required")
+	p.Ref, _ = jp.GetString(jsonMediaSchema(body), "$ref")
+
+	op.Parameters = append(op.Parameters, p)
+	return
+}
+
+func (op *Operation) responseFromOpenAPI3(spec []byte, key string, value []byte) (err error) {
+	code, err := strconv.Atoi(key)
+	if key == "default" {
+		code = 0
+		err = nil
+	}
+	if err != nil {
+		if strings.ToUpper(key) == "2XX" {
+			// the success range is decoded like a 200 response
+			code, err = http.StatusOK, nil
+		} else if len(key) == 3 && strings.HasSuffix(strings.ToUpper(key), "XX") {
+			// error ranges like 4XX have no single status code to map
+			return nil
+		} else {
+			return err
+		}
+	}
+	if value, err = resolveLocal(spec, value); err != nil {
+		return
+	}
+
+	r := &Response{Code: code, specKey: key}
+	r.Description, _ = jp.GetString(value, "description")
+	r.Ref, _ = jp.GetString(jsonMediaSchema(value), "$ref")
+
+	op.Responses = append(op.Responses, r)
+	return nil
+}
+
+// schemaType returns the type of the schema at keys and whether it allows null. Of OpenAPI 3.1 type arrays
+// like [string, "null"] the first type other than null is used, OpenAPI 3.0 schemas use nullable.
+func schemaType(value []byte, keys ...string) (typeName string, nullable bool) {
+	schema, _, _, err := jp.Get(value, keys...)
+	if err != nil {
+		return
+	}
+	nullable, _ = jp.GetBoolean(schema, "nullable")
+	typeValue, dataType, _, _ := jp.Get(schema, "type")
+	switch dataType {
+	case jp.String:
+		typeName = string(typeValue)
+	case jp.Array:
+		_, _ = jp.ArrayEach(typeValue, func(value []byte, _ jp.ValueType, _ int, _ error) {
+			if string(value) == "null" {
+				nullable = true
+			} else if typeName == "" {
+				typeName = string(value)
+			}
+		})
+	}
+	return
+}
+
+// jsonMediaSchema returns the schema of the json media type in the content of a request body or response.
+// When no json media type is present the first media type is used.
+func jsonMediaSchema(value []byte) (schema []byte) {
+	var first, jsonSchema []byte
+	_ = jp.ObjectEach(value, func(key []byte, value []byte, _ jp.ValueType, _ int) error {
+		mediaType := string(key)
+		mediaSchema, _, _, _ := jp.Get(value, "schema")
+		if first == nil {
+			first = mediaSchema
+		}
+		if mediaType == "application/json" || (jsonSchema == nil && strings.Contains(mediaType, "json")) {
+			jsonSchema = mediaSchema
+		}
+		return nil
+	}, "content")
+	if jsonSchema != nil {
+		return jsonSchema
+	}
+	return first
+}
+
+// resolveLocal follows a local $ref such as #/components/parameters/Id and returns the referenced value.
+// Values without a $ref are returned as is.
+func resolveLocal(spec, value []byte) ([]byte, error) {
+	seen := map[string]bool{}
+	for {
+		ref, _ := jp.GetString(value, "$ref")
+		if ref == "" {
+			return value, nil
+		}
+		if seen[ref] {
+			return nil, fmt.Errorf("circular $ref %s", ref)
+		}
+		seen[ref] = true
+		resolved, _, _, err := jp.Get(spec, refKeys(ref)...)
+		if err != nil {
+			return nil, fmt.Errorf("unable to resolve %s : %w", ref, err)
+		}
+		value = resolved
+	}
+}
+
+// refKeys splits a local $ref into the keys used to look it up with jsonparser.
+func refKeys(ref string) []string {
+	keys := strings.Split(strings.TrimPrefix(ref, "#/"), "/")
+	for i, key := range keys {
+		keys[i] = strings.NewReplacer("~1", "/", "~0", "~").Replace(key)
+	}
+	return keys
+}
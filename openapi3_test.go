@@ -0,0 +1,97 @@
+package swaggerlt
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestOperationFromOpenAPI3SuccessRange(t *testing.T) {
+	spec := []byte(`{"openapi": "3.0.3"}`)
+	value := []byte(`{
+		"responses": {
+			"400": {"content": {"application/json": {"schema": {"$ref": "#/components/schemas/Error"}}}},
+			"2XX": {"content": {"application/json": {"schema": {"$ref": "#/components/schemas/User"}}}},
+			"5XX": {"description": "server error"}
+		}
+	}`)
+	op, err := OperationFromOpenAPI3(spec, "/users", "put", value)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(op.Responses) != 2 {
+		t.Fatalf("expected the 400 and 2XX responses, got %d", len(op.Responses))
+	}
+	success := op.successResponse()
+	if success == nil || success.Code != 200 || success.Ref != "#/components/schemas/User" {
+		t.Fatalf("unexpected success response %+v", success)
+	}
+	if success.key() != "2XX" {
+		t.Errorf("expected key 2XX, got %s", success.key())
+	}
+}
+
+func TestSuccessResponse(t *testing.T) {
+	for _, test := range []struct {
+		name     string
+		codes    []int
+		expected int
+	}{
+		{"error first", []int{404, 201, 200}, 201},
+		{"default", []int{400, 0}, 0},
+		{"2xx before default", []int{0, 204}, 204},
+		{"errors only", []int{400, 500}, -1},
+	} {
+		t.Run(test.name, func(t *testing.T) {
+			op := &Operation{}
+			for _, code := range test.codes {
+				op.Responses = append(op.Responses, &Response{Code: code})
+			}
+			success := op.successResponse()
+			if test.expected == -1 {
+				if success != nil {
+					t.Fatalf("expected no success response, got %d", success.Code)
+				}
+				return
+			}
+			if success == nil || success.Code != test.expected {
+				t.Fatalf("expected %d, got %+v", test.expected, success)
+			}
+		})
+	}
+}
+
+func TestSchemaType(t *testing.T) {
+	for _, test := range []struct {
+		name     string
+		schema   string
+		expected string
+		nullable bool
+	}{
+		{"type", `{"type": "string"}`, "string", false},
+		{"nullable", `{"type": "integer", "nullable": true}`, "integer", true},
+		{"type array", `{"type": ["string", "null"]}`, "string", true},
+		{"null first", `{"type": ["null", "boolean"]}`, "boolean", true},
+		{"single member", `{"type": ["number"]}`, "number", false},
+		{"no type", `{"$ref": "#/components/schemas/User"}`, "", false},
+	} {
+		t.Run(test.name, func(t *testing.T) {
+			typeName, nullable := schemaType([]byte(test.schema))
+			if typeName != test.expected || nullable != test.nullable {
+				t.Errorf("expected %q %v, got %q %v", test.expected, test.nullable, typeName, nullable)
+			}
+		})
+	}
+
+	if items, _ := schemaType([]byte(`{"type": "array", "items": {"type": ["string", "null"]}}`), "items"); items != "string" {
+		t.Errorf("expected string items, got %q", items)
+	}
+}
+
+func TestOperationFromOpenAPI3ResponsePointer(t *testing.T) {
+	spec := []byte(`{"openapi": "3.1.0"}`)
+	value := []byte(`{"responses": {"2/0": {"$ref": "#/components/responses/Missing"}}}`)
+	_, err := OperationFromOpenAPI3(spec, "/users", "get", value)
+	if err == nil || !strings.Contains(err.Error(), "#/paths/~1users/get/responses/2~10") {
+		t.Errorf("expected the escaped response pointer, got %v", err)
+	}
+}
@@ -1,6 +1,8 @@
 package swaggerlt
 
 import (
+	"errors"
+	"fmt"
 	jp "github.com/buger/jsonparser"
 	"strconv"
 )
@@ -13,37 +15,99 @@ type Operation struct {
 	Parameters     []*Parameter `json:"parameters,omitempty"`
 	Responses      []*Response  `json:"responses,omitempty"`
 	XOperationName string       `json:"x-operation-name"`
-	RawData        []byte       `json:"-"`
+	OperationID    string       `json:"operationId"`
+	// Security lists alternative sets of security scheme names, the spec wide security when the operation has none.
+	Security [][]string `json:"security,omitempty"`
+	// GoName is the method name assigned by Generator.Operations.
+	GoName  string `json:"goName"`
+	RawData []byte `json:"-"`
 }
 
 func OperationFromSpec(path, verb string, value []byte) (op *Operation, err error) {
 	op = &Operation{Path: path, Verb: verb}
-	if _, err = jp.ArrayEach(value, op.tags, "tags"); err != nil {
-		return
-	}
+	// tags are optional
+	_, _ = jp.ArrayEach(value, op.tags, "tags")
 	op.Description, _ = jp.GetString(value, "description")
-	if _, err = jp.ArrayEach(value, op.parameters, "parameters"); err != nil {
+	pointer := jsonPointer("paths", path, verb)
+	if op.Parameters, err = parametersFromSpec(value, pointer); err != nil {
 		return
 	}
-	if err = jp.ObjectEach(value, op.responses, "responses"); err != nil {
+	err = jp.ObjectEach(value, func(key []byte, value []byte, dataType jp.ValueType, offset int) error {
+		return specError(pointer+"/responses/"+pointerKey(string(key)), op.responses(key, value, dataType, offset))
+	}, "responses")
+	if errors.Is(err, jp.KeyPathNotFoundError) {
+		// reported as no responses when the operation is built
+		err = nil
+	}
+	if err != nil {
+		err = specError(pointer+"/responses", err)
 		return
 	}
 
 	op.XOperationName, _ = jp.GetString(value, "x-operation-name")
+	op.OperationID, _ = jp.GetString(value, "operationId")
+	op.Security = securityRequirements(value, "security")
 	op.RawData = value
 
 	return
 }
 
+var verbs = []string{"get", "put", "post", "delete", "options", "head", "patch", "trace"}
+
+func isVerb(verb string) bool {
+	for _, v := range verbs {
+		if v == verb {
+			return true
+		}
+	}
+	return false
+}
+
+// inheritParameters adds path level parameters that are not overridden by the operation.
+func (op *Operation) inheritParameters(parameters []*Parameter) {
+	var inherited []*Parameter
+	for _, p := range parameters {
+		overridden := false
+		for _, opParam := range op.Parameters {
+			if opParam.NameOrig == p.NameOrig && opParam.In == p.In {
+				overridden = true
+			}
+		}
+		if !overridden {
+			inherited = append(inherited, p)
+		}
+	}
+	op.Parameters = append(inherited, op.Parameters...)
+}
+
 func (op *Operation) tags(value []byte, _ jp.ValueType, _ int, _ error) {
 	op.Tags = append(op.Tags, string(value))
 }
 
-func (op *Operation) parameters(value []byte, _ jp.ValueType, _ int, _ error) {
-	p := &Parameter{}
-	p.Name, _ = jp.GetString(value, "name")
+// parametersFromSpec reads the swagger 2.0 parameters of an operation or path item at pointer.
+func parametersFromSpec(value []byte, pointer string) (params []*Parameter, err error) {
+	index := 0
+	_, _ = jp.ArrayEach(value, func(value []byte, _ jp.ValueType, _ int, _ error) {
+		if err != nil {
+			return
+		}
+		var p *Parameter
+		if p, err = parameterFromSpec(value); err != nil {
+			err = specError(fmt.Sprintf("%s/parameters/%d", pointer, index), err)
+			return
+		}
+		params = append(params, p)
+		index++
+	}, "parameters")
+	return
+}
+
+func parameterFromSpec(value []byte) (p *Parameter, err error) {
+	p = &Parameter{}
 	p.NameOrig, _ = jp.GetString(value, "name")
-	p.Name = toGoNameLower(p.Name)
+	if p.Name, err = toGoNameLower(p.NameOrig); err != nil {
+		return nil, fmt.Errorf("parameter name : %w", err)
+	}
 
 	p.In, _ = jp.GetString(value, "in")
 	p.Description, _ = jp.GetString(value, "description")
@@ -55,7 +119,22 @@ func (op *Operation) parameters(value []byte, _ jp.ValueType, _ int, _ error) {
 	p.Ref, _ = jp.GetString(value, "schema", "$ref")
 
 	// TODO minimum, maximum, multipleOf
-	op.Parameters = append(op.Parameters, p)
+	return
+}
+
+// successResponse returns the response decoded when the status code is below 300, the first 2xx
+// response or else the default response. It is nil when the operation declares neither.
+func (op *Operation) successResponse() *Response {
+	var fallback *Response
+	for _, r := range op.Responses {
+		if r.Code >= 200 && r.Code < 300 {
+			return r
+		}
+		if r.Code == 0 && fallback == nil {
+			fallback = r
+		}
+	}
+	return fallback
 }
 
 func (op *Operation) responses(key []byte, value []byte, _ jp.ValueType, _ int) error {
@@ -66,7 +145,7 @@ func (op *Operation) responses(key []byte, value []byte, _ jp.ValueType, _ int)
 		err = nil
 	}
 	if err != nil {
-		return err
+		return fmt.Errorf("response status code %q : %w", key, err)
 	}
 	r := &Response{Code: code}
 	r.Description, _ = jp.GetString(value, "description")
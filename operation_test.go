@@ -0,0 +1,29 @@
+package swaggerlt
+
+import (
+	"errors"
+	"testing"
+)
+
+func TestOperationFromSpecWithoutTags(t *testing.T) {
+	value := []byte(`{"operationId": "getUser", "responses": {"200": {"schema": {"$ref": "#/definitions/v0.User"}}}}`)
+	op, err := OperationFromSpec("/v0/users", "get", value)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(op.Tags) != 0 || len(op.Responses) != 1 {
+		t.Fatalf("unexpected operation %+v", op)
+	}
+}
+
+func TestOperationFromSpecError(t *testing.T) {
+	value := []byte(`{"responses": {"ok": {"description": "not a status code"}}}`)
+	_, err := OperationFromSpec("/v0/users", "get", value)
+	var se *SpecError
+	if !errors.As(err, &se) {
+		t.Fatalf("expected a SpecError, got %v", err)
+	}
+	if expected := "#/paths/~1v0~1users/get/responses/ok"; se.Pointer != expected {
+		t.Errorf("expected pointer %s, got %s", expected, se.Pointer)
+	}
+}
@@ -0,0 +1,99 @@
+package swaggerlt
+
+import (
+	"context"
+	"math"
+	"sync"
+	"time"
+)
+
+// Limiter delays requests. Wait blocks until a request of the operation may be sent or ctx is done.
+type Limiter interface {
+	Wait(ctx context.Context, operation string) error
+}
+
+// RateLimiter is a token bucket allowing rate requests per second on average with bursts of up to burst requests.
+// It limits all operations together, use OperationLimiter for limits per operation.
+type RateLimiter struct {
+	rate  float64
+	burst float64
+
+	mutex  sync.Mutex
+	tokens float64
+	last   time.Time
+}
+
+// NewRateLimiter returns a RateLimiter with a full bucket, burst is at least one. A rate of zero or less
+// never refills the bucket, after burst requests Wait blocks until its ctx is done.
+func NewRateLimiter(rate float64, burst int) *RateLimiter {
+	if burst < 1 {
+		burst = 1
+	}
+	return &RateLimiter{rate: rate, burst: float64(burst), tokens: float64(burst), last: time.Now()}
+}
+
+// Wait takes a token, waiting for it when the bucket is empty. The token is returned when ctx is done first.
+func (r *RateLimiter) Wait(ctx context.Context, _ string) error {
+	wait := r.reserve()
+	if wait <= 0 {
+		return nil
+	}
+	timer := time.NewTimer(wait)
+	defer timer.Stop()
+	select {
+	case <-ctx.Done():
+		r.mutex.Lock()
+		r.tokens++
+		r.mutex.Unlock()
+		return ctx.Err()
+	case <-timer.C:
+		return nil
+	}
+}
+
+// reserve takes a token, the bucket goes negative for waiting callers, and returns how long until it is available.
+func (r *RateLimiter) reserve() time.Duration {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+
+	now := time.Now()
+	if r.rate > 0 {
+		r.tokens += now.Sub(r.last).Seconds() * r.rate
+	}
+	if r.tokens > r.burst {
+		r.tokens = r.burst
+	}
+	r.last = now
+
+	r.tokens--
+	if r.tokens >= 0 {
+		return 0
+	}
+	if r.rate <= 0 {
+		// the bucket is not refilled, wait until ctx is done
+		return math.MaxInt64
+	}
+	wait := -r.tokens / r.rate * float64(time.Second)
+	if wait >= math.MaxInt64 {
+		return math.MaxInt64
+	}
+	return time.Duration(wait)
+}
+
+// OperationLimiter applies the limiter of an operation, keyed by the generated method name, or Default
+// for the operations without one. Operations without a limiter are not delayed.
+type OperationLimiter struct {
+	Default    Limiter
+	Operations map[string]Limiter
+}
+
+func (o *OperationLimiter) Wait(ctx context.Context, operation string) error {
+	limiter := o.Operations[operation]
+	if limiter == nil {
+		limiter = o.Default
+	}
+	if limiter == nil {
+		return nil
+	}
+	return limiter.Wait(ctx, operation)
+}
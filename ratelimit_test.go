@@ -0,0 +1,84 @@
+package swaggerlt
+
+import (
+	"context"
+	"errors"
+	"math"
+	"testing"
+	"time"
+)
+
+func TestRateLimiter(t *testing.T) {
+	for _, test := range []struct {
+		name  string
+		rate  float64
+		burst int
+		// waits are the expected waits of consecutive requests without refill in between
+		waits []time.Duration
+	}{
+		{"burst", 10, 3, []time.Duration{0, 0, 0, 100 * time.Millisecond, 200 * time.Millisecond}},
+		{"burst of at least one", 2, 0, []time.Duration{0, 500 * time.Millisecond}},
+		{"no refill", 0, 1, []time.Duration{0, math.MaxInt64, math.MaxInt64}},
+		{"negative rate", -1, 2, []time.Duration{0, 0, math.MaxInt64}},
+		{"tiny rate", 1e-300, 1, []time.Duration{0, math.MaxInt64}},
+	} {
+		t.Run(test.name, func(t *testing.T) {
+			r := NewRateLimiter(test.rate, test.burst)
+			for i, expected := range test.waits {
+				r.last = time.Now()
+				wait := r.reserve()
+				// refill of the few nanoseconds between setting last and reserving
+				if wait > expected || wait < expected-time.Millisecond {
+					t.Errorf("request %d : expected a wait of %v, got %v", i, expected, wait)
+				}
+			}
+		})
+	}
+}
+
+func TestRateLimiterWait(t *testing.T) {
+	r := NewRateLimiter(0, 1)
+	if err := r.Wait(context.Background(), ""); err != nil {
+		t.Fatal(err)
+	}
+	for i := 0; i < 2; i++ {
+		ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+		err := r.Wait(ctx, "")
+		cancel()
+		if !errors.Is(err, context.DeadlineExceeded) {
+			t.Fatalf("expected the wait to last until the deadline, got %v", err)
+		}
+	}
+	if r.tokens != 0 {
+		t.Errorf("expected the tokens of cancelled waits to be returned, got %v", r.tokens)
+	}
+
+	r = NewRateLimiter(100, 1)
+	start := time.Now()
+	for i := 0; i < 3; i++ {
+		if err := r.Wait(context.Background(), ""); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if elapsed := time.Since(start); elapsed < 15*time.Millisecond {
+		t.Errorf("expected two waits of 10ms, took %v", elapsed)
+	}
+}
+
+func TestOperationLimiter(t *testing.T) {
+	limited := NewRateLimiter(0, 1)
+	o := &OperationLimiter{Operations: map[string]Limiter{"CreateUser": limited}}
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+	for i := 0; i < 3; i++ {
+		if err := o.Wait(ctx, "GetUser"); err != nil {
+			t.Fatalf("operations without a limiter are not delayed, got %v", err)
+		}
+	}
+	if err := o.Wait(ctx, "CreateUser"); err != nil {
+		t.Fatal(err)
+	}
+	if err := o.Wait(ctx, "CreateUser"); err == nil {
+		t.Error("expected CreateUser to be limited")
+	}
+}
@@ -0,0 +1,39 @@
+package swaggerlt
+
+import (
+	"sort"
+	"sync"
+)
+
+// refGraph holds the definitions reachable from the generated operations. Its edges are discovered
+// while generating: each operation or definition adds the refs it uses and every ref is handed out
+// for generation exactly once, one breadth first level at a time.
+type refGraph struct {
+	mutex   sync.Mutex
+	seen    map[string]bool
+	pending []string
+}
+
+func newRefGraph() *refGraph {
+	return &refGraph{seen: map[string]bool{}}
+}
+
+// add records a use of the definition ref.
+func (r *refGraph) add(ref string) {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+	if !r.seen[ref] {
+		r.seen[ref] = true
+		r.pending = append(r.pending, ref)
+	}
+}
+
+// next returns the refs added since the last call in sorted order.
+func (r *refGraph) next() []string {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+	refs := r.pending
+	r.pending = nil
+	sort.Strings(refs)
+	return refs
+}
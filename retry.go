@@ -0,0 +1,95 @@
+package swaggerlt
+
+import (
+	"math/rand"
+	"net/http"
+	"strconv"
+	"time"
+)
+
+// RetryPolicy retries requests that failed with a transport error or were answered with 429 or a 5xx status.
+// The wait doubles with every attempt and is randomized between half and all of it, a Retry-After header
+// of the response is used instead when present. Responses asking for a longer wait than MaxBackoff are
+// returned without retrying.
+//
+// Requests with methods that are not idempotent, POST and PATCH, are only retried with RetryNonIdempotent
+// or when they carry an Idempotency-Key header.
+type RetryPolicy struct {
+	// MaxAttempts is the number of attempts including the first one, 3 when zero.
+	MaxAttempts int
+	// InitialBackoff is the wait before the second attempt, 100 milliseconds when zero.
+	InitialBackoff time.Duration
+	// MaxBackoff limits the wait between attempts, including the wait asked for by Retry-After, 10 seconds when zero.
+	MaxBackoff time.Duration
+	// RetryNonIdempotent retries POST and PATCH requests as well.
+	RetryNonIdempotent bool
+}
+
+// backoff returns the wait before the next attempt and whether the request should be sent again,
+// attempt is the number of attempts made so far.
+func (p *RetryPolicy) backoff(request *http.Request, response *http.Response, err error, attempt int) (time.Duration, bool) {
+	if p == nil || request.Context().Err() != nil {
+		return 0, false
+	}
+	maxAttempts := p.MaxAttempts
+	if maxAttempts == 0 {
+		maxAttempts = 3
+	}
+	if attempt >= maxAttempts || !p.idempotent(request) {
+		return 0, false
+	}
+	if err == nil && response.StatusCode != http.StatusTooManyRequests && response.StatusCode < 500 {
+		return 0, false
+	}
+
+	initial, limit := p.InitialBackoff, p.MaxBackoff
+	if initial == 0 {
+		initial = 100 * time.Millisecond
+	}
+	if limit == 0 {
+		limit = 10 * time.Second
+	}
+
+	if response != nil {
+		if wait, ok := retryAfter(response.Header.Get("Retry-After")); ok {
+			if wait > limit {
+				// retrying sooner than asked would likely fail again
+				return 0, false
+			}
+			return wait, true
+		}
+	}
+	wait := initial
+	for i := 1; i < attempt && wait < limit; i++ {
+		wait *= 2
+	}
+	if wait > limit {
+		wait = limit
+	}
+	return wait/2 + time.Duration(rand.Int63n(int64(wait/2)+1)), true
+}
+
+func (p *RetryPolicy) idempotent(request *http.Request) bool {
+	switch request.Method {
+	case http.MethodPost, http.MethodPatch:
+		return p.RetryNonIdempotent || request.Header.Get("Idempotency-Key") != ""
+	}
+	return true
+}
+
+// retryAfter parses a Retry-After header in seconds or as an http date.
+func retryAfter(value string) (time.Duration, bool) {
+	if value == "" {
+		return 0, false
+	}
+	if seconds, err := strconv.Atoi(value); err == nil && seconds >= 0 {
+		return time.Duration(seconds) * time.Second, true
+	}
+	if date, err := http.ParseTime(value); err == nil {
+		if wait := time.Until(date); wait > 0 {
+			return wait, true
+		}
+		return 0, true
+	}
+	return 0, false
+}
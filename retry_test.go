@@ -0,0 +1,99 @@
+package swaggerlt
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"testing"
+	"time"
+)
+
+func TestRetryAfter(t *testing.T) {
+	for _, test := range []struct {
+		name  string
+		value string
+		wait  time.Duration
+		ok    bool
+	}{
+		{"missing", "", 0, false},
+		{"seconds", "120", 2 * time.Minute, true},
+		{"zero", "0", 0, true},
+		{"negative", "-1", 0, false},
+		{"past date", "Wed, 21 Oct 2015 07:28:00 GMT", 0, true},
+		{"invalid", "soon", 0, false},
+	} {
+		t.Run(test.name, func(t *testing.T) {
+			wait, ok := retryAfter(test.value)
+			if wait != test.wait || ok != test.ok {
+				t.Errorf("expected %v %v, got %v %v", test.wait, test.ok, wait, ok)
+			}
+		})
+	}
+
+	future := time.Now().Add(time.Hour).UTC().Format(http.TimeFormat)
+	if wait, ok := retryAfter(future); !ok || wait < 59*time.Minute || wait > time.Hour {
+		t.Errorf("expected about an hour, got %v %v", wait, ok)
+	}
+}
+
+func TestBackoff(t *testing.T) {
+	transportErr := errors.New("connection reset")
+	status := func(code int, retryAfter string) *http.Response {
+		response := &http.Response{StatusCode: code, Header: http.Header{}}
+		if retryAfter != "" {
+			response.Header.Set("Retry-After", retryAfter)
+		}
+		return response
+	}
+	policy := &RetryPolicy{MaxAttempts: 5, InitialBackoff: time.Second, MaxBackoff: 5 * time.Second}
+
+	for _, test := range []struct {
+		name     string
+		policy   *RetryPolicy
+		method   string
+		header   string
+		response *http.Response
+		err      error
+		attempt  int
+		min, max time.Duration
+		retry    bool
+	}{
+		{"no policy", nil, http.MethodGet, "", status(503, ""), nil, 1, 0, 0, false},
+		{"success", policy, http.MethodGet, "", status(200, ""), nil, 1, 0, 0, false},
+		{"client error", policy, http.MethodGet, "", status(404, ""), nil, 1, 0, 0, false},
+		{"first retry", policy, http.MethodGet, "", status(503, ""), nil, 1, 500 * time.Millisecond, time.Second, true},
+		{"third retry", policy, http.MethodGet, "", status(500, ""), nil, 3, 2 * time.Second, 4 * time.Second, true},
+		{"capped", policy, http.MethodGet, "", status(429, ""), nil, 4, 2500 * time.Millisecond, 5 * time.Second, true},
+		{"transport error", policy, http.MethodGet, "", nil, transportErr, 1, 500 * time.Millisecond, time.Second, true},
+		{"attempts exhausted", policy, http.MethodGet, "", status(503, ""), nil, 5, 0, 0, false},
+		{"default attempts", &RetryPolicy{}, http.MethodGet, "", status(503, ""), nil, 3, 0, 0, false},
+		{"retry after", policy, http.MethodGet, "", status(429, "3"), nil, 1, 3 * time.Second, 3 * time.Second, true},
+		{"retry after beyond max backoff", policy, http.MethodGet, "", status(503, "86400"), nil, 1, 0, 0, false},
+		{"post", policy, http.MethodPost, "", status(503, ""), nil, 1, 0, 0, false},
+		{"post with idempotency key", policy, http.MethodPost, "key", status(503, ""), nil, 1, 500 * time.Millisecond, time.Second, true},
+		{"post opted in", &RetryPolicy{RetryNonIdempotent: true}, http.MethodPatch, "", status(503, ""), nil, 1, 50 * time.Millisecond, 100 * time.Millisecond, true},
+	} {
+		t.Run(test.name, func(t *testing.T) {
+			request, _ := http.NewRequest(test.method, "https://example.com", nil)
+			if test.header != "" {
+				request.Header.Set("Idempotency-Key", test.header)
+			}
+			wait, retry := test.policy.backoff(request, test.response, test.err, test.attempt)
+			if retry != test.retry {
+				t.Fatalf("expected retry %v, got %v", test.retry, retry)
+			}
+			if retry && (wait < test.min || wait > test.max) {
+				t.Errorf("expected a wait between %v and %v, got %v", test.min, test.max, wait)
+			}
+		})
+	}
+}
+
+func TestBackoffCancelled(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	request, _ := http.NewRequestWithContext(ctx, http.MethodGet, "https://example.com", nil)
+	if _, retry := (&RetryPolicy{}).backoff(request, nil, context.Canceled, 1); retry {
+		t.Error("a cancelled request was retried")
+	}
+}
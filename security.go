@@ -0,0 +1,149 @@
+package swaggerlt
+
+import (
+	"fmt"
+	jp "github.com/buger/jsonparser"
+	"github.com/dave/jennifer/jen"
+	"sort"
+	"strings"
+)
+
+// securityScheme is a swagger security definition or an OpenAPI 3 security scheme.
+type securityScheme struct {
+	// Type is apiKey, basic or bearer, oauth2 and openIdConnect schemes are bearer
+	Type string
+	// Name and In locate an apiKey
+	Name string
+	In   string
+}
+
+// securityRequirements reads a security array, each requirement lists the schemes that must all be applied.
+// The result is nil when the array is absent and empty when it is present but empty, which disables security.
+func securityRequirements(value []byte, keys ...string) (requirements [][]string) {
+	_, _ = jp.ArrayEach(value, func(value []byte, _ jp.ValueType, _ int, _ error) {
+		requirement := []string{}
+		_ = jp.ObjectEach(value, func(key []byte, _ []byte, _ jp.ValueType, _ int) error {
+			requirement = append(requirement, string(key))
+			return nil
+		})
+		requirements = append(requirements, requirement)
+	}, keys...)
+	if requirements == nil {
+		if _, dataType, _, err := jp.Get(value, keys...); err == nil && dataType == jp.Array {
+			requirements = [][]string{}
+		}
+	}
+	return
+}
+
+// securitySchemes returns the security schemes of the spec by name. Unsupported schemes are reported
+// as warnings and left out, the schemes are read once.
+func (g *Generator) securitySchemes() map[string]*securityScheme {
+	g.schemesOnce.Do(func() { g.schemes = g.readSecuritySchemes() })
+	return g.schemes
+}
+
+func (g *Generator) readSecuritySchemes() map[string]*securityScheme {
+	keys := []string{"securityDefinitions"}
+	if g.openAPI3 {
+		keys = []string{"components", "securitySchemes"}
+	}
+
+	schemes := map[string]*securityScheme{}
+	_ = jp.ObjectEach(g.specBytes, func(key []byte, value []byte, _ jp.ValueType, _ int) error {
+		name := string(key)
+		scheme := &securityScheme{}
+		scheme.Type, _ = jp.GetString(value, "type")
+		switch scheme.Type {
+		case "apiKey":
+			scheme.Name, _ = jp.GetString(value, "name")
+			scheme.In, _ = jp.GetString(value, "in")
+		case "http":
+			// the scheme is an http authentication scheme name, which is case-insensitive
+			httpScheme, _ := jp.GetString(value, "scheme")
+			scheme.Type = strings.ToLower(httpScheme)
+		case "oauth2", "openIdConnect":
+			scheme.Type = "bearer"
+		}
+		switch {
+		case scheme.Type == "apiKey" && (scheme.In == "header" || scheme.In == "query"):
+		case scheme.Type == "basic", scheme.Type == "bearer":
+		default:
+			g.report.warnf(jsonPointer(append(keys, name)...), "unsupported security scheme, requests are sent without it")
+			return nil
+		}
+		schemes[name] = scheme
+		return nil
+	}, keys...)
+	return schemes
+}
+
+// securityOptions renders WithCredential and a With<Scheme> option per security scheme of the spec.
+func (g *Generator) securityOptions(f *jen.File, option func(name string, params []jen.Code, body ...jen.Code)) {
+	credentials := func(scheme string) *jen.Statement {
+		return jen.Id("s").Dot("Credentials").Index(jen.Lit(scheme))
+	}
+
+	f.Comment("WithCredential sets the credential of a security scheme of the spec.")
+	option("WithCredential", []jen.Code{jen.Id("scheme").String(), jen.Id("credential").Qual(runtimePackage, "Credential")},
+		jen.Id("s").Dot("Credentials").Index(jen.Id("scheme")).Op("=").Id("credential"))
+
+	schemes := g.securitySchemes()
+	var names []string
+	for name := range schemes {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	for _, name := range names {
+		scheme := schemes[name]
+		optionName := "With" + camelCase(name)
+		if camelCase(name) == "" || isClientOption(optionName) {
+			optionName = "With" + camelCase(name) + "Credential"
+		}
+		switch scheme.Type {
+		case "apiKey":
+			f.Comment(fmt.Sprintf("%s sends key in the %s %s for the %s security scheme.", optionName, scheme.In, scheme.Name, name))
+			option(optionName, []jen.Code{jen.Id("key").String()},
+				credentials(name).Op("=").Op("&").Qual(runtimePackage, "APIKey").Values(jen.Dict{
+					jen.Id("Name"): jen.Lit(scheme.Name),
+					jen.Id("In"):   jen.Lit(scheme.In),
+					jen.Id("Key"):  jen.Id("key"),
+				}))
+		case "basic":
+			f.Comment(fmt.Sprintf("%s sends basic authentication for the %s security scheme.", optionName, name))
+			option(optionName, []jen.Code{jen.List(jen.Id("username"), jen.Id("password")).String()},
+				credentials(name).Op("=").Op("&").Qual(runtimePackage, "BasicAuth").Values(jen.Dict{
+					jen.Id("Username"): jen.Id("username"),
+					jen.Id("Password"): jen.Id("password"),
+				}))
+		case "bearer":
+			f.Comment(fmt.Sprintf("%s sends the tokens of source as bearer tokens for the %s security scheme.", optionName, name))
+			option(optionName, []jen.Code{jen.Id("source").Qual(runtimePackage, "TokenSource")},
+				credentials(name).Op("=").Op("&").Qual(runtimePackage, "BearerToken").Values(jen.Dict{
+					jen.Id("Source"): jen.Id("source"),
+				}))
+		}
+	}
+}
+
+// securityCode returns the statement setting the security requirements of op on the request helper h,
+// nil when op has none.
+func (g *Generator) securityCode(op *Operation, pointer string) *jen.Statement {
+	schemes := g.securitySchemes()
+	var requirements []jen.Code
+	for _, requirement := range op.Security {
+		var names []jen.Code
+		for _, name := range requirement {
+			if schemes[name] == nil {
+				g.report.warnf(pointer+"/security", "security scheme %s is not defined or unsupported", name)
+			}
+			names = append(names, jen.Lit(name))
+		}
+		requirements = append(requirements, jen.Values(names...))
+	}
+	if len(requirements) == 0 {
+		return nil
+	}
+	return jen.Id("h").Dot("Security").Op("=").Index().Qual(runtimePackage, "SecurityRequirement").Values(requirements...)
+}
@@ -0,0 +1,36 @@
+package swaggerlt
+
+import (
+	"testing"
+)
+
+func TestReadSecuritySchemes(t *testing.T) {
+	for _, test := range []struct {
+		name     string
+		scheme   string
+		expected string
+	}{
+		{"bearer", `{"type": "http", "scheme": "bearer"}`, "bearer"},
+		{"capitalized bearer", `{"type": "http", "scheme": "Bearer"}`, "bearer"},
+		{"capitalized basic", `{"type": "http", "scheme": "Basic"}`, "basic"},
+		{"api key", `{"type": "apiKey", "in": "header", "name": "X-Key"}`, "apiKey"},
+		{"oauth2", `{"type": "oauth2"}`, "bearer"},
+		{"digest", `{"type": "http", "scheme": "digest"}`, ""},
+		{"api key in cookie", `{"type": "apiKey", "in": "cookie", "name": "key"}`, ""},
+	} {
+		t.Run(test.name, func(t *testing.T) {
+			spec := []byte(`{"openapi": "3.0.3", "components": {"securitySchemes": {"auth": ` + test.scheme + `}}}`)
+			g := &Generator{specBytes: spec, openAPI3: true, report: &Report{}}
+			scheme := g.readSecuritySchemes()["auth"]
+			if test.expected == "" {
+				if scheme != nil || g.report.Count(SeverityWarning) != 1 {
+					t.Errorf("expected an unsupported scheme warning, got %+v", scheme)
+				}
+				return
+			}
+			if scheme == nil || scheme.Type != test.expected {
+				t.Errorf("expected type %s, got %+v", test.expected, scheme)
+			}
+		})
+	}
+}
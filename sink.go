@@ -0,0 +1,135 @@
+package swaggerlt
+
+import (
+	"archive/zip"
+	"io"
+	"os"
+	"path"
+	"path/filepath"
+	"sort"
+	"sync"
+)
+
+// FileSink receives the generated files. Names are slash separated and relative to the
+// generated import path root, like smapiv0/client/client.go.
+//
+// WriteFile is called concurrently while types are generated.
+type FileSink interface {
+	WriteFile(name string, data []byte) error
+}
+
+// PruningSink is a FileSink which can also read and remove files, allowing files
+// generated by an earlier run that are no longer produced to be deleted.
+type PruningSink interface {
+	FileSink
+	ReadFile(name string) ([]byte, error)
+	Remove(name string) error
+}
+
+// DiskSink writes files below Dir, creating directories as needed.
+type DiskSink struct {
+	Dir string
+}
+
+func (d *DiskSink) path(name string) string {
+	return filepath.Join(d.Dir, filepath.FromSlash(name))
+}
+
+func (d *DiskSink) WriteFile(name string, data []byte) error {
+	path := d.path(name)
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return err
+	}
+	return os.WriteFile(path, data, 0644)
+}
+
+func (d *DiskSink) ReadFile(name string) ([]byte, error) {
+	return os.ReadFile(d.path(name))
+}
+
+// Remove deletes the file and any directories below Dir left empty by it.
+func (d *DiskSink) Remove(name string) error {
+	if err := os.Remove(d.path(name)); err != nil {
+		return err
+	}
+	for dir := path.Dir(name); dir != "." && dir != "/"; dir = path.Dir(dir) {
+		if entries, err := os.ReadDir(d.path(dir)); err != nil || len(entries) > 0 {
+			break
+		}
+		if err := os.Remove(d.path(dir)); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// MemorySink keeps the generated files in memory.
+type MemorySink struct {
+	mutex sync.Mutex
+	files map[string][]byte
+}
+
+func NewMemorySink() *MemorySink {
+	return &MemorySink{files: map[string][]byte{}}
+}
+
+func (m *MemorySink) WriteFile(name string, data []byte) error {
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+	m.files[name] = append([]byte(nil), data...)
+	return nil
+}
+
+// Files returns a copy of the files written so far keyed by name.
+func (m *MemorySink) Files() map[string][]byte {
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+	files := make(map[string][]byte, len(m.files))
+	for name, data := range m.files {
+		files[name] = data
+	}
+	return files
+}
+
+// Names returns the sorted names of the files written so far.
+func (m *MemorySink) Names() []string {
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+	var names []string
+	for name := range m.files {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// ZipSink writes the generated files to a zip archive. Files are buffered so the archive
+// entries are in name order, Close writes the archive.
+type ZipSink struct {
+	w      io.Writer
+	memory *MemorySink
+}
+
+func NewZipSink(w io.Writer) *ZipSink {
+	return &ZipSink{w: w, memory: NewMemorySink()}
+}
+
+func (z *ZipSink) WriteFile(name string, data []byte) error {
+	return z.memory.WriteFile(name, data)
+}
+
+// Close writes the buffered files to the archive. It does not close the underlying writer.
+func (z *ZipSink) Close() (err error) {
+	zw := zip.NewWriter(z.w)
+	files := z.memory.Files()
+	for _, name := range z.memory.Names() {
+		var entry io.Writer
+		if entry, err = zw.CreateHeader(&zip.FileHeader{Name: name, Method: zip.Deflate}); err != nil {
+			return
+		}
+		if _, err = entry.Write(files[name]); err != nil {
+			return
+		}
+	}
+	return zw.Close()
+}
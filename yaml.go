@@ -0,0 +1,149 @@
+package swaggerlt
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"gopkg.in/yaml.v3"
+	"path/filepath"
+	"strings"
+)
+
+// isYaml reports if a spec should be treated as yaml, either by file extension or by
+// sniffing the content for something other than a json object.
+func isYaml(fileName string, data []byte) bool {
+	switch strings.ToLower(filepath.Ext(fileName)) {
+	case ".yaml", ".yml":
+		return true
+	case ".json":
+		return false
+	}
+	trimmed := bytes.TrimSpace(data)
+	return len(trimmed) > 0 && trimmed[0] != '{'
+}
+
+// yamlToJson converts a yaml document to json while keeping the key order of mappings,
+// so paths, properties and responses are processed in the same order as written.
+func yamlToJson(data []byte) ([]byte, error) {
+	var doc yaml.Node
+	if err := yaml.Unmarshal(data, &doc); err != nil {
+		return nil, err
+	}
+	buf := &bytes.Buffer{}
+	if err := writeYamlNode(buf, &doc); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+func writeYamlNode(buf *bytes.Buffer, node *yaml.Node) error {
+	switch node.Kind {
+	case yaml.DocumentNode:
+		if len(node.Content) == 0 {
+			buf.WriteString("null")
+			return nil
+		}
+		return writeYamlNode(buf, node.Content[0])
+	case yaml.AliasNode:
+		return writeYamlNode(buf, node.Alias)
+	case yaml.MappingNode:
+		pairs, err := mappingPairs(node)
+		if err != nil {
+			return err
+		}
+		buf.WriteByte('{')
+		for i, pair := range pairs {
+			if i > 0 {
+				buf.WriteByte(',')
+			}
+			// keys such as response codes are written as json strings
+			key, err := json.Marshal(pair[0].Value)
+			if err != nil {
+				return err
+			}
+			buf.Write(key)
+			buf.WriteByte(':')
+			if err = writeYamlNode(buf, pair[1]); err != nil {
+				return err
+			}
+		}
+		buf.WriteByte('}')
+	case yaml.SequenceNode:
+		buf.WriteByte('[')
+		for i, item := range node.Content {
+			if i > 0 {
+				buf.WriteByte(',')
+			}
+			if err := writeYamlNode(buf, item); err != nil {
+				return err
+			}
+		}
+		buf.WriteByte(']')
+	case yaml.ScalarNode:
+		var value any = node.Value
+		switch node.ShortTag() {
+		case "!!null", "!!bool", "!!int", "!!float":
+			if err := node.Decode(&value); err != nil {
+				return err
+			}
+		}
+		// timestamps and other tags without a json type keep their text, like an unquoted 2021-05-01
+		marshal, err := json.Marshal(value)
+		if err != nil {
+			return fmt.Errorf("line %d: %w", node.Line, err)
+		}
+		buf.Write(marshal)
+	default:
+		return fmt.Errorf("line %d: unsupported yaml node kind %d", node.Line, node.Kind)
+	}
+	return nil
+}
+
+// mappingPairs returns the keys and values of a mapping with merge keys (<<) expanded. The keys of the
+// mapping override merged keys and mappings merged first override those merged later.
+func mappingPairs(node *yaml.Node) (pairs [][2]*yaml.Node, err error) {
+	index := map[string]int{}
+	set := func(key, value *yaml.Node, override bool) {
+		if i, ok := index[key.Value]; ok {
+			if override {
+				pairs[i][1] = value
+			}
+			return
+		}
+		index[key.Value] = len(pairs)
+		pairs = append(pairs, [2]*yaml.Node{key, value})
+	}
+
+	for i := 0; i+1 < len(node.Content); i += 2 {
+		key, value := node.Content[i], node.Content[i+1]
+		if key.ShortTag() != "!!merge" {
+			set(key, value, true)
+			continue
+		}
+		sources := []*yaml.Node{value}
+		if resolved := resolveAlias(value); resolved.Kind == yaml.SequenceNode {
+			sources = resolved.Content
+		}
+		for _, source := range sources {
+			source = resolveAlias(source)
+			if source.Kind != yaml.MappingNode {
+				return nil, fmt.Errorf("line %d: merge key value is not a mapping", key.Line)
+			}
+			var merged [][2]*yaml.Node
+			if merged, err = mappingPairs(source); err != nil {
+				return nil, err
+			}
+			for _, pair := range merged {
+				set(pair[0], pair[1], false)
+			}
+		}
+	}
+	return
+}
+
+func resolveAlias(node *yaml.Node) *yaml.Node {
+	for node.Kind == yaml.AliasNode {
+		node = node.Alias
+	}
+	return node
+}
@@ -0,0 +1,42 @@
+package swaggerlt
+
+import (
+	"testing"
+)
+
+func TestYamlToJson(t *testing.T) {
+	for _, test := range []struct {
+		name     string
+		yaml     string
+		expected string
+	}{
+		{"key order", "b: 1\na: 2\n", `{"b":1,"a":2}`},
+		{"scalars", "s: text\ni: 3\nf: 1.5\nb: true\nn: null\nq: '42'\n",
+			`{"s":"text","i":3,"f":1.5,"b":true,"n":null,"q":"42"}`},
+		{"status codes", "responses:\n  200: {description: ok}\n", `{"responses":{"200":{"description":"ok"}}}`},
+		{"timestamps keep their text", "example: 2021-05-01\nenum: [2021-05-01T10:00:00Z]\n",
+			`{"example":"2021-05-01","enum":["2021-05-01T10:00:00Z"]}`},
+		{"binary keeps its text", "data: !!binary aGVsbG8=\n", `{"data":"aGVsbG8="}`},
+		{"alias", "a: &x {type: string}\nb: *x\n", `{"a":{"type":"string"},"b":{"type":"string"}}`},
+		{"merge", "base: &base {type: object, description: base}\nuser:\n  <<: *base\n  description: user\n",
+			`{"base":{"type":"object","description":"base"},"user":{"type":"object","description":"user"}}`},
+		{"merge after keys", "base: &base {a: 1, b: 2}\nm:\n  b: 3\n  <<: *base\n",
+			`{"base":{"a":1,"b":2},"m":{"b":3,"a":1}}`},
+		{"merge sequence", "x: &x {a: 1}\ny: &y {a: 2, b: 2}\nm:\n  <<: [*x, *y]\n",
+			`{"x":{"a":1},"y":{"a":2,"b":2},"m":{"a":1,"b":2}}`},
+	} {
+		t.Run(test.name, func(t *testing.T) {
+			data, err := yamlToJson([]byte(test.yaml))
+			if err != nil {
+				t.Fatal(err)
+			}
+			if string(data) != test.expected {
+				t.Errorf("expected %s, got %s", test.expected, data)
+			}
+		})
+	}
+
+	if _, err := yamlToJson([]byte("a: &a 1\nm:\n  <<: *a\n")); err == nil {
+		t.Error("expected an error merging a scalar")
+	}
+}